@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Stopper is implemented by servers which support graceful stop, e.g. *grpc.Server.
+type Stopper interface {
+	GracefulStop()
+	Stop()
+}
+
+// FromIOCloser returns close function which closes the io.Closer (e.g. *sql.DB or *os.File).
+func FromIOCloser(c io.Closer) CloseFn {
+	return func(context.Context) error {
+		return c.Close()
+	}
+}
+
+// FromHTTPServer returns close function which gracefully shuts down the server.
+func FromHTTPServer(srv *http.Server) CloseFn {
+	return srv.Shutdown
+}
+
+// FromFunc returns close function which calls fn.
+func FromFunc(fn func()) CloseFn {
+	return func(context.Context) error {
+		fn()
+		return nil
+	}
+}
+
+// FromStopper returns close function which stops the server gracefully.
+// The server is stopped forcibly when the context is done before the graceful stop completes,
+// the close function returns after the graceful stop is interrupted by the forced one.
+func FromStopper(s Stopper) CloseFn {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.GracefulStop()
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			s.Stop()
+			<-done
+			return ctx.Err()
+		}
+	}
+}
+
+// FromCancel returns close function which cancels the context.
+func FromCancel(cancel context.CancelFunc) CloseFn {
+	return FromFunc(cancel)
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/nbgrp/pkg/closer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+type ioCloser struct {
+	err error
+}
+
+func (c ioCloser) Close() error {
+	return c.err
+}
+
+type stopper struct {
+	release  chan struct{}
+	graceful atomic.Bool
+	forced   atomic.Bool
+}
+
+func (s *stopper) GracefulStop() {
+	<-s.release
+	s.graceful.Store(true)
+}
+
+func (s *stopper) Stop() {
+	s.forced.Store(true)
+	close(s.release)
+}
+
+func TestAdapters(t *testing.T) {
+	t.Run("io closer", func(t *testing.T) {
+		errTest := errors.New("test error")
+
+		require.NoError(t, FromIOCloser(ioCloser{})(context.Background()))
+		require.ErrorIs(t, FromIOCloser(ioCloser{err: errTest})(context.Background()), errTest)
+	})
+
+	t.Run("http server", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		srv := &http.Server{ReadHeaderTimeout: time.Second}
+		served := make(chan error, 1)
+		go func() {
+			served <- srv.Serve(ln)
+		}()
+
+		require.NoError(t, FromHTTPServer(srv)(context.Background()))
+		require.ErrorIs(t, <-served, http.ErrServerClosed)
+	})
+
+	t.Run("func", func(t *testing.T) {
+		var called bool
+		require.NoError(t, FromFunc(func() { called = true })(context.Background()))
+		assert.True(t, called)
+	})
+
+	t.Run("graceful stopper", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		s := &stopper{release: make(chan struct{})}
+		close(s.release)
+
+		require.NoError(t, FromStopper(s)(context.Background()))
+		assert.True(t, s.graceful.Load())
+		assert.False(t, s.forced.Load())
+	})
+
+	t.Run("forced stopper", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		s := &stopper{release: make(chan struct{})}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		require.ErrorIs(t, FromStopper(s)(ctx), context.DeadlineExceeded)
+		assert.True(t, s.forced.Load())
+		assert.True(t, s.graceful.Load(), "graceful stop must be finished")
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		require.NoError(t, FromCancel(cancel)(context.Background()))
+		require.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
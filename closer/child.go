@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"context"
+)
+
+func (c *closer) Child(name string, opts ...Option) Closer {
+	_, child := New(*c.ctx.Load(), opts...)
+
+	e := &entry{child: true}
+	e.name = name
+	e.fn = func(ctx context.Context) error {
+		child.once.Do(func() {
+			child.closeAll(ctx)
+		})
+		return child.Err()
+	}
+	detach, err := c.add(e)
+	if err != nil {
+		detach = c.addLate(e)
+	}
+	child.detach = detach
+
+	return child
+}
+
+// splitChildren separates close functions of the child closers from the other ones.
+func splitChildren(funcs []*entry) (children, rest []*entry) {
+	rest = make([]*entry, 0, len(funcs))
+	for _, e := range funcs {
+		if e.child {
+			children = append(children, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	return children, rest
+}
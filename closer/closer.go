@@ -5,9 +5,15 @@ package closer
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
 	"os/signal"
+	"reflect"
+	"runtime"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type CloseFn func(ctx context.Context) error
@@ -20,25 +26,95 @@ type Closer interface {
 	// Done returns signal channel.
 	Done() <-chan struct{}
 	// Err returns a joint error based on close functions errors.
+	// Close functions which did not finish in time are reported by a TimeoutError,
+	// panics of close functions are reported by PanicError.
 	Err() error
-	// CloseAll runs close functions in arbitrary order.
+	// Errors returns errors of the close functions in completion order after CloseAll is done.
+	// Every error is a FuncError holding the registration name.
+	Errors() []error
+	// Wait blocks until CloseAll is done or the context is done and returns the closer error
+	// or the context error respectively.
+	Wait(ctx context.Context) error
+	// Report returns outcomes of the close functions in completion order after CloseAll is done.
+	Report() []FuncReport
+	// State returns the closer state.
+	State() State
+	// Dump writes the closer state, registered close functions and stacks of all goroutines.
+	Dump(w io.Writer) error
+	// DeclarePhase declares the phase which depends on the specified phases.
+	// Close functions of the phase run before close functions of its dependencies.
+	// It returns ErrPhaseCycle if the declaration produces a dependency cycle.
+	DeclarePhase(name string, dependsOn ...string) error
+	// Child returns a closer which is closed as a single close function of this closer
+	// registered with the name. Closing the parent closes its children before any other close function.
+	Child(name string, opts ...Option) Closer
+	// CloseAll runs close functions phase by phase. Within a phase close functions run
+	// concurrently in arbitrary order or sequentially in reverse registration order (see WithMode).
 	CloseAll()
 }
 
 // Adder allows registering functions to clean up resources.
 type Adder interface {
-	// Add adds close function.
-	Add(CloseFn)
+	// Add adds close function and returns a function which removes it from the closer.
+	// Close functions added after CloseAll started are handled according to the LatePolicy.
+	Add(CloseFn, ...AddOption) (remove func())
+	// TryAdd is like Add but returns ErrClosed if CloseAll has already started.
+	TryAdd(CloseFn, ...AddOption) (remove func(), err error)
+}
+
+// FuncReport describes the outcome of a close function.
+type FuncReport struct {
+	// Name is the registration name or the close function name if the registration is unnamed.
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Err      error
+	// Panic holds the value the close function panicked with.
+	Panic any
+	// TimedOut reports that CloseAll stopped waiting for the close function
+	// or did not call it because the deadline had already passed.
+	TimedOut bool
+	// Skipped reports that the close function was not run due to a previous failure (see WithStopOnError).
+	Skipped bool
+}
+
+func (r FuncReport) failed() bool {
+	return r.Err != nil || r.TimedOut
+}
+
+type entry struct {
+	addOptions
+	fn      CloseFn
+	child   bool
+	removed atomic.Bool
 }
 
 type closer struct {
-	done  chan struct{}
-	ctx   atomic.Pointer[context.Context]
-	err   atomic.Value
-	funcs atomic.Pointer[[]CloseFn]
-	once  sync.Once
+	opts   options
+	done   chan struct{}
+	ctx    atomic.Pointer[context.Context]
+	funcs  atomic.Pointer[[]*entry]
+	report atomic.Pointer[[]FuncReport]
+	mu     sync.Mutex // serializes report updates
+	phases atomic.Pointer[phaseGraph]
+	state  atomic.Int32
+	once   sync.Once
+	detach func()
+
+	// closeCtx is the context passed into the close functions added after CloseAll started.
+	closeCtx atomic.Pointer[context.Context]
+	// abortCtx is canceled when the shutdown escalates, it cancels the context of close functions.
+	abortCtx context.Context
+	abort    context.CancelFunc
 }
 
+// ErrClosed is returned by TryAdd when CloseAll has already started.
+var ErrClosed = errors.New("closer is closed")
+
+// closedFuncs marks the closer which functions were taken by CloseAll.
+var closedFuncs []*entry
+
 var _, global = New(context.Background())
 
 // SetContext sets context into the global Closer.
@@ -47,8 +123,63 @@ func SetContext(ctx context.Context) {
 }
 
 // Add adds close function into the global Closer.
-func Add(f CloseFn) {
-	global.Add(f)
+func Add(f CloseFn, opts ...AddOption) (remove func()) {
+	return global.Add(f, opts...)
+}
+
+// TryAdd tries to add close function into the global Closer.
+func TryAdd(f CloseFn, opts ...AddOption) (remove func(), err error) {
+	return global.TryAdd(f, opts...)
+}
+
+// AddNamed adds named close function into the global Closer.
+func AddNamed(name string, f CloseFn, opts ...AddOption) (remove func()) {
+	return global.AddNamed(name, f, opts...)
+}
+
+// AddWithTimeout adds close function with timeout into the global Closer.
+func AddWithTimeout(f CloseFn, timeout time.Duration, opts ...AddOption) (remove func()) {
+	return global.AddWithTimeout(f, timeout, opts...)
+}
+
+// Errors returns close functions errors of the global Closer.
+func Errors() []error {
+	return global.Errors()
+}
+
+// Wait waits for the global Closer CloseAll is done.
+func Wait(ctx context.Context) error {
+	return global.Wait(ctx)
+}
+
+// Report returns close functions outcomes of the global Closer.
+func Report() []FuncReport {
+	return global.Report()
+}
+
+// Child returns a child closer of the global Closer.
+func Child(name string, opts ...Option) Closer {
+	return global.Child(name, opts...)
+}
+
+// CurrentState returns the state of the global Closer.
+func CurrentState() State {
+	return global.State()
+}
+
+// AddWithRetry adds close function with retry policy into the global Closer.
+func AddWithRetry(f CloseFn, policy RetryPolicy, opts ...AddOption) (remove func()) {
+	return global.AddWithRetry(f, policy, opts...)
+}
+
+// Dump writes the global Closer registrations and stacks of all goroutines.
+func Dump(w io.Writer) error {
+	return global.Dump(w)
+}
+
+// DeclarePhase declares the phase of the global Closer.
+func DeclarePhase(name string, dependsOn ...string) error {
+	return global.DeclarePhase(name, dependsOn...)
 }
 
 // Done returns signal channel of the global Closer.
@@ -73,23 +204,30 @@ func New(ctx context.Context, opts ...Option) (context.Context, *closer) {
 		o = opt(o)
 	}
 
-	var cancel context.CancelFunc
-	if len(o.signals) > 0 {
-		ctx, cancel = signal.NotifyContext(ctx, o.signals...)
-	}
-
-	c := &closer{done: make(chan struct{})}
 	if ctx == nil {
 		panic("cannot create closer with nil context")
 	}
+
+	c := &closer{
+		opts: o,
+		done: make(chan struct{}),
+	}
+	c.abortCtx, c.abort = context.WithCancel(context.Background())
+
+	if len(o.signals) > 0 || len(o.signalHandlers) > 0 {
+		cancel := context.CancelFunc(func() {})
+		if len(o.signals) > 0 {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, o.notifySignals()...)
+		go c.watchSignals(sigCh, cancel)
+	}
 	c.ctx.Store(&ctx)
 
 	if o.ctxCancel {
 		go func() {
-			if cancel != nil {
-				defer cancel()
-			}
-
 			<-ctx.Done()
 			c.CloseAll() //nolint:contextcheck
 		}()
@@ -105,27 +243,105 @@ func (c *closer) SetContext(ctx context.Context) {
 	c.ctx.Store(&ctx)
 }
 
-func (c *closer) Add(f CloseFn) {
-	var funcs []CloseFn
-	old := c.funcs.Load()
-	if old != nil {
-		funcs = make([]CloseFn, len(*old)+1)
-		copy(funcs, *old)
-		funcs[len(funcs)-1] = f
-	} else {
-		funcs = []CloseFn{f}
+func (c *closer) Add(f CloseFn, opts ...AddOption) (remove func()) {
+	e := &entry{fn: f}
+	for _, opt := range opts {
+		e.addOptions = opt(e.addOptions)
+	}
+
+	remove, err := c.add(e)
+	if err != nil {
+		return c.addLate(e)
+	}
+	return remove
+}
+
+func (c *closer) TryAdd(f CloseFn, opts ...AddOption) (remove func(), err error) {
+	e := &entry{fn: f}
+	for _, opt := range opts {
+		e.addOptions = opt(e.addOptions)
+	}
+	return c.add(e)
+}
+
+func (c *closer) add(e *entry) (remove func(), err error) {
+	if e.name == "" {
+		e.name = funcName(e.fn)
 	}
 
-	for !c.funcs.CompareAndSwap(old, &funcs) {
-		old = c.funcs.Load()
+	for {
+		old := c.funcs.Load()
+		if old == &closedFuncs {
+			return nil, ErrClosed
+		}
+
+		var funcs []*entry
 		if old != nil {
-			funcs = make([]CloseFn, len(*old)+1)
+			funcs = make([]*entry, len(*old)+1)
 			copy(funcs, *old)
-			funcs[len(funcs)-1] = f
+			funcs[len(funcs)-1] = e
+		} else {
+			funcs = []*entry{e}
+		}
+
+		if c.funcs.CompareAndSwap(old, &funcs) {
+			return func() {
+				c.remove(e)
+			}, nil
 		}
 	}
 }
 
+// addLate handles the close function added after CloseAll started according to the late policy.
+func (c *closer) addLate(e *entry) (remove func()) {
+	if c.opts.latePolicy == LatePanic {
+		panic(ErrClosed)
+	}
+
+	c.appendReport([]FuncReport{c.run(*c.closeCtx.Load(), e)})
+	return func() {}
+}
+
+func (c *closer) remove(e *entry) {
+	if e.removed.Swap(true) {
+		return
+	}
+
+	for {
+		old := c.funcs.Load()
+		if old == nil {
+			return
+		}
+
+		i := indexOf(*old, e)
+		if i < 0 {
+			return
+		}
+
+		funcs := make([]*entry, 0, len(*old)-1)
+		funcs = append(funcs, (*old)[:i]...)
+		funcs = append(funcs, (*old)[i+1:]...)
+		if c.funcs.CompareAndSwap(old, &funcs) {
+			return
+		}
+	}
+}
+
+// AddNamed is a shortcut for Add with WithName.
+func (c *closer) AddNamed(name string, f CloseFn, opts ...AddOption) (remove func()) {
+	return c.Add(f, append(opts[:len(opts):len(opts)], WithName(name))...)
+}
+
+// AddWithTimeout is a shortcut for Add with WithFuncTimeout.
+func (c *closer) AddWithTimeout(f CloseFn, timeout time.Duration, opts ...AddOption) (remove func()) {
+	return c.Add(f, append(opts[:len(opts):len(opts)], WithFuncTimeout(timeout))...)
+}
+
+// AddWithRetry is a shortcut for Add with WithRetry.
+func (c *closer) AddWithRetry(f CloseFn, policy RetryPolicy, opts ...AddOption) (remove func()) {
+	return c.Add(f, append(opts[:len(opts):len(opts)], WithRetry(policy))...)
+}
+
 func (c *closer) Done() <-chan struct{} {
 	return c.done
 }
@@ -133,38 +349,357 @@ func (c *closer) Done() <-chan struct{} {
 func (c *closer) Err() error {
 	select {
 	case <-c.done:
-		err, _ := c.err.Load().(error)
-		return err
+		if report := c.report.Load(); report != nil {
+			return joinErrors(*report)
+		}
+		return nil
 	default:
 		return nil
 	}
 }
 
-func (c *closer) CloseAll() { //nolint:gocognit
+func (c *closer) Errors() []error {
+	var errs []error
+	for _, res := range c.Report() {
+		switch {
+		case res.TimedOut:
+			errs = append(errs, &FuncError{
+				Name: res.Name,
+				Err:  &TimeoutError{Funcs: []TimedOutFunc{{Name: res.Name, Elapsed: res.Duration}}},
+			})
+		case res.Err != nil:
+			errs = append(errs, &FuncError{Name: res.Name, Err: res.Err})
+		}
+	}
+	return errs
+}
+
+func (c *closer) Wait(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return c.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *closer) Report() []FuncReport {
+	select {
+	case <-c.done:
+		if report := c.report.Load(); report != nil {
+			return append([]FuncReport(nil), *report...)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (c *closer) CloseAll() {
 	c.once.Do(func() {
-		defer close(c.done)
+		if c.detach != nil {
+			c.detach()
+		}
+		c.closeAll(context.WithoutCancel(*c.ctx.Load()))
+	})
+}
 
-		ctx := context.WithoutCancel(*c.ctx.Load())
-		funcs := c.funcs.Swap(nil)
-		if funcs == nil {
-			return
+func (c *closer) closeAll(ctx context.Context) {
+	defer close(c.done)
+
+	c.state.Store(int32(StateDraining))
+	defer c.state.Store(int32(StateClosed))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(c.abortCtx, cancel)()
+
+	if c.opts.grace > 0 {
+		timer := time.AfterFunc(c.opts.grace, c.abort)
+		defer timer.Stop()
+	}
+
+	if c.opts.drainDelay > 0 {
+		waitDrainDelay(ctx, c.opts.drainDelay)
+	}
+
+	if c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if h := c.opts.hooks.OnCloseStart; h != nil {
+		h(ctx)
+	}
+
+	c.closeCtx.Store(&ctx)
+	defer func() {
+		// close functions added after CloseAll is done get the shutdown context without cancel
+		lateCtx := context.WithoutCancel(ctx)
+		c.closeCtx.Store(&lateCtx)
+	}()
+	funcs := c.takeFuncs()
+
+	var graph phaseGraph
+	if g := c.phases.Load(); g != nil {
+		graph = *g
+	}
+
+	children, funcs := splitChildren(funcs)
+	waves := graph.closeOrder(funcs)
+	if len(children) > 0 {
+		waves = append([][]*entry{children}, waves...)
+	}
+
+	report := make([]FuncReport, 0, len(funcs)+len(children))
+	for _, wave := range waves {
+		if c.opts.stopOnError && hasFailure(report) {
+			report = append(report, skip(wave)...)
+			continue
+		}
+		report = append(report, c.closeWave(ctx, wave)...)
+	}
+	c.appendReport(report)
+
+	if h := c.opts.hooks.OnCloseDone; h != nil {
+		h(ctx, time.Since(start), joinErrors(report))
+	}
+
+	if c.opts.repanic {
+		var panicErr *PanicError
+		if errors.As(joinErrors(report), &panicErr) {
+			panic(panicErr)
+		}
+	}
+}
+
+// appendReport appends outcomes of the close functions into the closer report.
+func (c *closer) appendReport(res []FuncReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var report []FuncReport
+	if old := c.report.Load(); old != nil {
+		report = append(report, *old...)
+	}
+	report = append(report, res...)
+	c.report.Store(&report)
+}
+
+// takeFuncs detaches registered close functions from the closer skipping the removed ones.
+// Close functions added afterward are handled as late ones.
+func (c *closer) takeFuncs() []*entry {
+	funcs := c.funcs.Swap(&closedFuncs)
+	if funcs == nil {
+		return nil
+	}
+
+	active := make([]*entry, 0, len(*funcs))
+	for _, e := range *funcs {
+		if !e.removed.Load() {
+			active = append(active, e)
 		}
+	}
+	return active
+}
 
-		errCh := make(chan error, len(*funcs))
-		for _, fn := range *funcs {
-			go func(fn CloseFn) {
-				errCh <- fn(ctx)
-			}(fn)
+func (c *closer) closeWave(ctx context.Context, funcs []*entry) []FuncReport {
+	if c.opts.mode == ModeLIFO {
+		return c.closeSequentially(ctx, funcs)
+	}
+	return c.closeConcurrently(ctx, funcs)
+}
+
+// closeSequentially runs close functions one by one in reverse registration order.
+func (c *closer) closeSequentially(ctx context.Context, funcs []*entry) []FuncReport {
+	report := make([]FuncReport, 0, len(funcs))
+	for i := len(funcs) - 1; i >= 0; i-- {
+		res := c.run(ctx, funcs[i])
+		report = append(report, res)
+
+		if c.opts.stopOnError && res.failed() {
+			return append(report, skip(funcs[:i])...)
+		}
+	}
+	return report
+}
+
+// skip reports close functions as skipped in reverse registration order.
+func skip(funcs []*entry) []FuncReport {
+	report := make([]FuncReport, 0, len(funcs))
+	for i := len(funcs) - 1; i >= 0; i-- {
+		report = append(report, FuncReport{
+			Name:    funcs[i].name,
+			Skipped: true,
+		})
+	}
+	return report
+}
+
+func hasFailure(report []FuncReport) bool {
+	for _, res := range report {
+		if res.failed() {
+			return true
 		}
+	}
+	return false
+}
 
-		errs := make([]error, 0, len(*funcs))
-		for i := 0; i < cap(errs); i++ {
-			if err := <-errCh; err != nil {
-				errs = append(errs, err)
+// closeConcurrently runs close functions concurrently limiting the number of simultaneously
+// running ones if the max concurrency is set.
+func (c *closer) closeConcurrently(ctx context.Context, funcs []*entry) []FuncReport {
+	var sem chan struct{}
+	if c.opts.maxConcurrency > 0 {
+		sem = make(chan struct{}, c.opts.maxConcurrency)
+	}
+
+	resCh := make(chan FuncReport, len(funcs))
+	for _, e := range funcs {
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		go func(e *entry) {
+			resCh <- c.run(ctx, e)
+			if sem != nil {
+				<-sem
 			}
+		}(e)
+	}
+
+	report := make([]FuncReport, 0, len(funcs))
+	for i := 0; i < len(funcs); i++ {
+		report = append(report, <-resCh)
+	}
+	return report
+}
+
+// run runs the close function notifying the hooks.
+func (c *closer) run(ctx context.Context, e *entry) FuncReport {
+	if h := c.opts.hooks.OnFnStart; h != nil {
+		h(ctx, e.name)
+	}
+
+	res := e.run(ctx)
+
+	if h := c.opts.hooks.OnFnDone; h != nil {
+		h(ctx, res)
+	}
+	return res
+}
+
+// run calls the close function and waits for it until the context deadline.
+// The close function is not called and is reported as timed out if the deadline has already passed.
+func (e *entry) run(ctx context.Context) (res FuncReport) {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	res = FuncReport{
+		Name:  e.name,
+		Start: time.Now(),
+	}
+	defer func() {
+		res.End = time.Now()
+		res.Duration = res.End.Sub(res.Start)
+	}()
+
+	defer func() {
+		var panicErr *PanicError
+		if errors.As(res.Err, &panicErr) {
+			res.Panic = panicErr.Value
 		}
-		if len(errs) > 0 {
-			c.err.Store(errors.Join(errs...))
+	}()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		res.TimedOut = true
+		return res
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		res.Err = e.callWithRetry(ctx)
+		return res
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.callWithRetry(ctx)
+	}()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case res.Err = <-errCh:
+	case <-timer.C:
+		res.TimedOut = true
+	}
+	return res
+}
+
+// call calls the close function and converts its panic into PanicError.
+func (e *entry) call(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{
+				Value: r,
+				Stack: debug.Stack(),
+			}
 		}
-	})
+	}()
+
+	return e.fn(ctx)
+}
+
+func joinErrors(report []FuncReport) error {
+	var (
+		errs    []error
+		timeout TimeoutError
+	)
+	for _, res := range report {
+		switch {
+		case res.TimedOut:
+			timeout.Funcs = append(timeout.Funcs, TimedOutFunc{Name: res.Name, Elapsed: res.Duration})
+		case res.Err != nil:
+			errs = append(errs, res.Err)
+		}
+	}
+	if len(timeout.Funcs) > 0 {
+		errs = append(errs, &timeout)
+	}
+	return errors.Join(errs...)
+}
+
+func indexOf(funcs []*entry, e *entry) int {
+	for i := range funcs {
+		if funcs[i] == e {
+			return i
+		}
+	}
+	return -1
+}
+
+// NameOf returns the name the close function is registered under with the options:
+// the name set by WithName or the function name. It helps wrappers of close functions
+// keep the names in the closer reports and errors.
+func NameOf(f CloseFn, opts ...AddOption) string {
+	var o addOptions
+	for _, opt := range opts {
+		o = opt(o)
+	}
+	if o.name != "" {
+		return o.name
+	}
+	return funcName(f)
+}
+
+func funcName(fn CloseFn) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
 }
@@ -6,10 +6,12 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"testing"
+	"time"
 
 	. "github.com/nbgrp/pkg/closer"
 	"github.com/stretchr/testify/assert"
@@ -110,4 +112,729 @@ func TestCloser(t *testing.T) {
 
 		assert.Equal(t, uint32(2), cnt.Load())
 	})
+
+	t.Run("phases", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		require.NoError(t, c.DeclarePhase("http", "db"))
+		require.NoError(t, c.DeclarePhase("db", "log"))
+
+		var (
+			mu    sync.Mutex
+			order []string
+		)
+		record := func(name string) CloseFn {
+			return func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			}
+		}
+
+		c.Add(record("log"), InPhase("log"))
+		c.Add(record("db #1"), InPhase("db"))
+		c.Add(record("http"), InPhase("http"))
+		c.Add(record("db #2"), InPhase("db"))
+
+		c.CloseAll()
+		<-c.Done()
+
+		require.Len(t, order, 4)
+		assert.Equal(t, "http", order[0])
+		assert.ElementsMatch(t, []string{"db #1", "db #2"}, order[1:3])
+		assert.Equal(t, "log", order[3])
+	})
+
+	t.Run("phase cycle", func(t *testing.T) {
+		_, c := New(context.Background())
+		require.NoError(t, c.DeclarePhase("a", "b"))
+		require.NoError(t, c.DeclarePhase("b", "c"))
+
+		err := c.DeclarePhase("c", "a")
+		require.ErrorIs(t, err, ErrPhaseCycle)
+		assert.ErrorContains(t, err, "c -> a -> b -> c")
+
+		require.ErrorIs(t, c.DeclarePhase("d", "d"), ErrPhaseCycle)
+		require.NoError(t, c.DeclarePhase("c", "d"))
+	})
+
+	t.Run("with timeout", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithTimeout(50*time.Millisecond))
+
+		release := make(chan struct{})
+		defer close(release)
+
+		c.Add(func(context.Context) error {
+			<-release
+			return nil
+		})
+		c.Add(func(context.Context) error {
+			<-release
+			return errors.New("test error")
+		}, InPhase("late"))
+		require.NoError(t, c.DeclarePhase("early", "late"))
+		c.Add(func(context.Context) error {
+			return nil
+		}, InPhase("early"))
+
+		start := time.Now()
+		c.CloseAll()
+		assert.Less(t, time.Since(start), time.Second)
+
+		err := c.Err()
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		require.Len(t, timeoutErr.Funcs, 2)
+		for _, f := range timeoutErr.Funcs {
+			assert.Contains(t, f.Name, "closer_test.TestCloser")
+			assert.GreaterOrEqual(t, f.Elapsed, time.Duration(0))
+		}
+	})
+
+	t.Run("add with timeout", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+
+		release := make(chan struct{})
+		defer close(release)
+
+		c.AddWithTimeout(func(context.Context) error {
+			<-release
+			return nil
+		}, 20*time.Millisecond)
+		c.AddWithTimeout(func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			return errors.New("test error")
+		}, time.Minute)
+		c.Add(func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			return nil
+		})
+
+		c.CloseAll()
+
+		err := c.Err()
+		require.ErrorContains(t, err, "test error")
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		require.Len(t, timeoutErr.Funcs, 1)
+		assert.GreaterOrEqual(t, timeoutErr.Funcs[0].Elapsed, 20*time.Millisecond)
+		assert.ErrorContains(t, err, "close timeout: ")
+	})
+
+	t.Run("report", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		errTest := errors.New("test error")
+
+		c.AddNamed("db", func(context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return errTest
+		})
+		c.Add(func(context.Context) error {
+			return nil
+		})
+
+		assert.Nil(t, c.Report())
+		c.CloseAll()
+
+		report := c.Report()
+		require.Len(t, report, 2)
+
+		assert.Contains(t, report[0].Name, "closer_test.TestCloser")
+		assert.NoError(t, report[0].Err)
+
+		assert.Equal(t, "db", report[1].Name)
+		assert.ErrorIs(t, report[1].Err, errTest)
+		assert.False(t, report[1].TimedOut)
+		assert.GreaterOrEqual(t, report[1].Duration, 20*time.Millisecond)
+		assert.Equal(t, report[1].Duration, report[1].End.Sub(report[1].Start))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+
+		var cnt atomic.Uint32
+		c.AddNamed("panic", func(context.Context) error {
+			panic("test panic")
+		})
+		c.AddWithTimeout(func(context.Context) error {
+			panic(errors.New("test error"))
+		}, time.Minute)
+		c.Add(func(context.Context) error {
+			cnt.Add(1)
+			return nil
+		})
+
+		c.CloseAll()
+		assert.Equal(t, uint32(1), cnt.Load())
+
+		err := c.Err()
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.NotEmpty(t, panicErr.Stack)
+		assert.NotNil(t, panicErr.PanicValue())
+		assert.ErrorContains(t, err, "close panic: test panic")
+		assert.ErrorContains(t, err, "close panic: test error")
+
+		for _, res := range c.Report() {
+			if res.Name == "panic" {
+				assert.Equal(t, "test panic", res.Panic)
+			}
+		}
+	})
+
+	t.Run("with repanic", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithRepanic())
+
+		var cnt atomic.Uint32
+		c.Add(func(context.Context) error {
+			panic("test panic")
+		})
+		c.Add(func(context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			cnt.Add(1)
+			return nil
+		})
+
+		assert.PanicsWithError(t, "close panic: test panic", c.CloseAll)
+		assert.Equal(t, uint32(1), cnt.Load())
+
+		<-c.Done()
+		var panicErr *PanicError
+		require.ErrorAs(t, c.Err(), &panicErr)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+
+		var cnt atomic.Uint32
+		inc := func(context.Context) error {
+			cnt.Add(1)
+			return nil
+		}
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				remove := c.AddNamed("tenant", inc)
+				if i%2 == 0 {
+					remove()
+					remove()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		remove := c.Add(func(context.Context) error {
+			return errors.New("test error")
+		})
+		remove()
+
+		c.CloseAll()
+		require.NoError(t, c.Err())
+		assert.Equal(t, uint32(50), cnt.Load())
+		assert.Len(t, c.Report(), 50)
+
+		assert.NotPanics(t, remove)
+	})
+
+	t.Run("child", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+
+		var (
+			mu    sync.Mutex
+			order []string
+		)
+		record := func(name string, err error) CloseFn {
+			return func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return err
+			}
+		}
+
+		c.Add(record("parent", nil))
+		child := c.Child("http")
+		child.Add(record("child", errors.New("child error")))
+		grandchild := child.Child("handlers")
+		grandchild.Add(record("grandchild", errors.New("grandchild error")))
+
+		c.CloseAll()
+
+		assert.Equal(t, []string{"grandchild", "child", "parent"}, order)
+		err := c.Err()
+		require.ErrorContains(t, err, "child error")
+		require.ErrorContains(t, err, "grandchild error")
+
+		<-child.Done()
+		require.ErrorContains(t, child.Err(), "child error")
+
+		errs := c.Errors()
+		require.Len(t, errs, 1)
+		var funcErr *FuncError
+		require.ErrorAs(t, errs[0], &funcErr)
+		assert.Equal(t, "http", funcErr.Name)
+		require.ErrorAs(t, child.Errors()[0], &funcErr)
+		assert.Equal(t, "handlers", funcErr.Name)
+	})
+
+	t.Run("child closed independently", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+
+		var cnt atomic.Uint32
+		child := c.Child("child")
+		child.Add(func(context.Context) error {
+			cnt.Add(1)
+			return errors.New("child error")
+		})
+
+		child.CloseAll()
+		require.ErrorContains(t, child.Err(), "child error")
+
+		c.CloseAll()
+		require.NoError(t, c.Err())
+		assert.Equal(t, uint32(1), cnt.Load())
+	})
+
+	t.Run("escalation by signals", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		exited := make(chan struct{})
+		_, c := New(context.Background(),
+			WithSignals(syscall.SIGUSR1),
+			WithEscalation(0, func() { close(exited) }),
+		)
+
+		started := make(chan struct{})
+		aborted := make(chan struct{})
+		c.Add(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			close(aborted)
+			<-exited
+			return ctx.Err()
+		})
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+		<-started
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+		<-aborted
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+		<-c.Done()
+		require.ErrorIs(t, c.Err(), context.Canceled)
+	})
+
+	t.Run("escalation by grace period", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithEscalation(20*time.Millisecond, nil))
+
+		c.Add(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		start := time.Now()
+		c.CloseAll()
+
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		require.ErrorIs(t, c.Err(), context.Canceled)
+	})
+
+	t.Run("late add", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		c.CloseAll()
+
+		remove, err := c.TryAdd(func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrClosed)
+		assert.Nil(t, remove)
+
+		var cnt atomic.Uint32
+		remove = c.AddNamed("late", func(ctx context.Context) error {
+			cnt.Add(1)
+			return errors.New("late error")
+		})
+		assert.NotPanics(t, remove)
+		assert.Equal(t, uint32(1), cnt.Load())
+
+		require.ErrorContains(t, c.Err(), "late error")
+		report := c.Report()
+		require.Len(t, report, 1)
+		assert.Equal(t, "late", report[0].Name)
+	})
+
+	t.Run("late add with panic policy", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithLatePolicy(LatePanic))
+		c.CloseAll()
+
+		assert.PanicsWithValue(t, ErrClosed, func() {
+			c.Add(func(context.Context) error {
+				return nil
+			})
+		})
+	})
+
+	t.Run("concurrent add and close", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		for i := 0; i < 50; i++ {
+			_, c := New(context.Background())
+
+			var cnt atomic.Uint32
+			wg := sync.WaitGroup{}
+			for j := 0; j < 100; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					c.Add(func(context.Context) error {
+						cnt.Add(1)
+						return nil
+					})
+				}()
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.CloseAll()
+			}()
+			wg.Wait()
+
+			<-c.Done()
+			require.Equal(t, uint32(100), cnt.Load())
+			require.Len(t, c.Report(), 100)
+		}
+	})
+
+	t.Run("with max concurrency", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithMaxConcurrency(3))
+
+		var running, peak, cnt atomic.Int32
+		for i := 0; i < 20; i++ {
+			c.Add(func(context.Context) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				cnt.Add(1)
+				return nil
+			})
+		}
+
+		c.CloseAll()
+
+		assert.Equal(t, int32(20), cnt.Load())
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+	})
+
+	t.Run("lifo mode", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithMode(ModeLIFO))
+
+		var order []int
+		for i := 0; i < 5; i++ {
+			i := i
+			c.Add(func(context.Context) error {
+				order = append(order, i)
+				return nil
+			})
+		}
+
+		c.CloseAll()
+
+		assert.Equal(t, []int{4, 3, 2, 1, 0}, order)
+	})
+
+	t.Run("lifo mode with expired timeout", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithMode(ModeLIFO), WithTimeout(10*time.Millisecond))
+
+		release := make(chan struct{})
+		defer close(release)
+
+		var called atomic.Bool
+		c.AddNamed("fast", func(context.Context) error {
+			called.Store(true)
+			return nil
+		})
+		c.AddNamed("hung", func(context.Context) error {
+			<-release
+			return nil
+		})
+
+		c.CloseAll()
+
+		assert.False(t, called.Load())
+		report := c.Report()
+		require.Len(t, report, 2)
+		for _, res := range report {
+			assert.True(t, res.TimedOut, res.Name)
+		}
+	})
+
+	t.Run("lifo mode with concurrent add", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithMode(ModeLIFO))
+
+		const workers, perWorker = 8, 50
+		closed := make([][]int, workers)
+
+		wg := sync.WaitGroup{}
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					i := i
+					c.Add(func(context.Context) error {
+						closed[w] = append(closed[w], i)
+						return nil
+					})
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		c.CloseAll()
+
+		for w := 0; w < workers; w++ {
+			require.Len(t, closed[w], perWorker)
+			for i, v := range closed[w] {
+				assert.Equal(t, perWorker-1-i, v)
+			}
+		}
+	})
+
+	t.Run("lifo mode with stop on error", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithMode(ModeLIFO), WithStopOnError())
+
+		var order []string
+		record := func(name string, err error) CloseFn {
+			return func(context.Context) error {
+				order = append(order, name)
+				return err
+			}
+		}
+
+		require.NoError(t, c.DeclarePhase("first", "second"))
+		c.AddNamed("second", record("second", nil), InPhase("second"))
+		c.AddNamed("a", record("a", nil), InPhase("first"))
+		c.AddNamed("b", record("b", errors.New("test error")), InPhase("first"))
+		c.AddNamed("c", record("c", nil), InPhase("first"))
+
+		c.CloseAll()
+
+		assert.Equal(t, []string{"c", "b"}, order)
+		require.ErrorContains(t, c.Err(), "test error")
+
+		report := c.Report()
+		require.Len(t, report, 4)
+		assert.Equal(t, "a", report[2].Name)
+		assert.True(t, report[2].Skipped)
+		assert.Equal(t, "second", report[3].Name)
+		assert.True(t, report[3].Skipped)
+	})
+
+	t.Run("lifo mode continues on error", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithMode(ModeLIFO))
+
+		var order []string
+		c.Add(func(context.Context) error {
+			order = append(order, "a")
+			return nil
+		})
+		c.Add(func(context.Context) error {
+			order = append(order, "b")
+			return errors.New("test error")
+		})
+
+		c.CloseAll()
+
+		assert.Equal(t, []string{"b", "a"}, order)
+		require.ErrorContains(t, c.Err(), "test error")
+	})
+
+	t.Run("with signal handlers", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		reloaded := make(chan os.Signal, 1)
+		dumped := make(chan string, 1)
+		_, c := New(context.Background(),
+			WithSignals(syscall.SIGTERM),
+			WithSignalHandler(func(_ Closer, sig os.Signal) {
+				reloaded <- sig
+			}, syscall.SIGHUP),
+			WithSignalHandler(func(c Closer, sig os.Signal) {
+				var buf strings.Builder
+				DumpHandler(&buf)(c, sig)
+				dumped <- buf.String()
+			}, syscall.SIGUSR2),
+		)
+
+		c.AddNamed("db", func(context.Context) error {
+			return nil
+		}, InPhase("storage"))
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		assert.Equal(t, syscall.SIGHUP, <-reloaded)
+		assert.Equal(t, StateRunning, c.State())
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+		dump := <-dumped
+		assert.Contains(t, dump, "closer state: running\nclose functions:\n\tdb (phase \"storage\")\n")
+		assert.Contains(t, dump, "goroutines:\n")
+		assert.Contains(t, dump, "closer.(*closer).watchSignals")
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+		<-c.Done()
+		assert.Equal(t, StateClosed, c.State())
+	})
+
+	t.Run("slow signal handler", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		_, c := New(context.Background(),
+			WithSignals(syscall.SIGTERM),
+			WithSignalHandler(func(Closer, os.Signal) {
+				close(started)
+				<-release
+			}, syscall.SIGHUP),
+		)
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		<-started
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+		select {
+		case <-c.Done():
+		case <-time.After(time.Second):
+			t.Fatal("shutdown is blocked by the signal handler")
+		}
+		close(release)
+	})
+
+	t.Run("wait and errors", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithMode(ModeLIFO))
+
+		release := make(chan struct{})
+		defer close(release)
+
+		errTest := errors.New("test error")
+		c.AddWithTimeout(func(context.Context) error {
+			<-release
+			return nil
+		}, 10*time.Millisecond, InPhase("hung"))
+		c.AddNamed("ok", func(context.Context) error {
+			return nil
+		})
+		c.AddNamed("failed", func(context.Context) error {
+			return errTest
+		})
+		assert.Nil(t, c.Errors())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, c.Wait(ctx), context.DeadlineExceeded)
+
+		go c.CloseAll()
+
+		err := c.Wait(context.Background())
+		require.ErrorIs(t, err, errTest)
+
+		errs := c.Errors()
+		require.Len(t, errs, 2)
+
+		var funcErr *FuncError
+		require.ErrorAs(t, errs[0], &funcErr)
+		assert.Equal(t, "failed", funcErr.Name)
+		assert.ErrorIs(t, errs[0], errTest)
+		assert.EqualError(t, errs[0], "failed: test error")
+
+		require.ErrorAs(t, errs[1], &funcErr)
+		assert.Contains(t, funcErr.Name, "closer_test.TestCloser")
+		var timeoutErr *TimeoutError
+		assert.ErrorAs(t, errs[1], &timeoutErr)
+	})
+
+	t.Run("combined add options", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		var child Closer = c.Child("child")
+
+		release := make(chan struct{})
+		defer close(release)
+
+		child.Add(func(context.Context) error {
+			<-release
+			return nil
+		}, WithName("hung"), WithFuncTimeout(10*time.Millisecond))
+
+		attempts := 0
+		child.Add(func(context.Context) error {
+			attempts++
+			if attempts < 2 {
+				return errors.New("transient error")
+			}
+			return nil
+		}, WithName("flaky"), WithRetry(RetryPolicy{MaxAttempts: 2}))
+
+		c.CloseAll()
+
+		assert.Equal(t, 2, attempts)
+		errs := child.Errors()
+		require.Len(t, errs, 1)
+		var funcErr *FuncError
+		require.ErrorAs(t, errs[0], &funcErr)
+		assert.Equal(t, "hung", funcErr.Name)
+		var timeoutErr *TimeoutError
+		assert.ErrorAs(t, errs[0], &timeoutErr)
+	})
 }
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package closertest provides a fake closer.Closer for testing resources cleanup.
+package closertest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nbgrp/pkg/closer"
+	"go.uber.org/goleak"
+)
+
+// Registration describes a close function registered in the fake Closer.
+type Registration struct {
+	// Name is the registration name or the close function name if the registration is unnamed
+	// (see closer.NameOf).
+	Name string
+	// Calls is the number of the shutdown runs which called the close function
+	// (retry attempts within a run are not counted).
+	Calls int
+	// Removed reports that the close function was removed from the closer.
+	Removed bool
+}
+
+// entry is a registration with the run of its last call.
+type entry struct {
+	Registration
+	lastRun int
+}
+
+// Closer is a fake closer.Closer which records registrations and runs close functions
+// sequentially in reverse registration order (closer.ModeLIFO), so the tests are deterministic.
+// Child closers share the registrations with their parent.
+type Closer struct {
+	closer.Closer
+	t   testing.TB
+	reg *registry
+}
+
+type registry struct {
+	mu      sync.Mutex
+	entries []*entry
+	run     int // the number of CloseAll calls
+}
+
+var _ closer.Closer = (*Closer)(nil)
+
+// New returns fake Closer. The options are applied after closer.WithMode(closer.ModeLIFO).
+func New(t testing.TB, opts ...closer.Option) *Closer {
+	t.Helper()
+
+	_, c := closer.New(context.Background(), append([]closer.Option{closer.WithMode(closer.ModeLIFO)}, opts...)...)
+	return &Closer{
+		Closer: c,
+		t:      t,
+		reg:    &registry{},
+	}
+}
+
+func (c *Closer) Add(f closer.CloseFn, opts ...closer.AddOption) (remove func()) {
+	f, opts, r := c.reg.record(f, opts)
+	return c.reg.onRemove(r, c.Closer.Add(f, opts...))
+}
+
+func (c *Closer) TryAdd(f closer.CloseFn, opts ...closer.AddOption) (remove func(), err error) {
+	f, opts, r := c.reg.record(f, opts)
+	remove, err = c.Closer.TryAdd(f, opts...)
+	if err != nil {
+		c.reg.forget(r)
+		return nil, err
+	}
+	return c.reg.onRemove(r, remove), nil
+}
+
+// AddNamed is a shortcut for Add with closer.WithName.
+func (c *Closer) AddNamed(name string, f closer.CloseFn, opts ...closer.AddOption) (remove func()) {
+	return c.Add(f, append(opts[:len(opts):len(opts)], closer.WithName(name))...)
+}
+
+// AddWithTimeout is a shortcut for Add with closer.WithFuncTimeout.
+func (c *Closer) AddWithTimeout(f closer.CloseFn, timeout time.Duration, opts ...closer.AddOption) (remove func()) {
+	return c.Add(f, append(opts[:len(opts):len(opts)], closer.WithFuncTimeout(timeout))...)
+}
+
+// AddWithRetry is a shortcut for Add with closer.WithRetry.
+func (c *Closer) AddWithRetry(f closer.CloseFn, policy closer.RetryPolicy, opts ...closer.AddOption) (remove func()) {
+	return c.Add(f, append(opts[:len(opts):len(opts)], closer.WithRetry(policy))...)
+}
+
+func (c *Closer) Child(name string, opts ...closer.Option) closer.Closer {
+	return &Closer{
+		Closer: c.Closer.Child(name, append([]closer.Option{closer.WithMode(closer.ModeLIFO)}, opts...)...),
+		t:      c.t,
+		reg:    c.reg,
+	}
+}
+
+// CloseAll runs close functions and fails the test if any of them leaves running goroutines.
+func (c *Closer) CloseAll() {
+	c.t.Helper()
+
+	defer goleak.VerifyNone(c.t, goleak.IgnoreCurrent())
+
+	c.reg.mu.Lock()
+	c.reg.run++
+	c.reg.mu.Unlock()
+
+	c.Closer.CloseAll()
+}
+
+// Registrations returns the recorded registrations in registration order.
+func (c *Closer) Registrations() []Registration {
+	c.reg.mu.Lock()
+	defer c.reg.mu.Unlock()
+
+	registrations := make([]Registration, 0, len(c.reg.entries))
+	for _, r := range c.reg.entries {
+		registrations = append(registrations, r.Registration)
+	}
+	return registrations
+}
+
+// AssertClosed fails the test if any registered close function was not called exactly once
+// or a removed close function was called. It returns whether the assertion succeeded.
+func (c *Closer) AssertClosed() bool {
+	c.t.Helper()
+
+	ok := true
+	for i, r := range c.Registrations() {
+		want := 1
+		if r.Removed {
+			want = 0
+		}
+		if r.Calls != want {
+			c.t.Errorf("close function #%d %q: called %d times, want %d", i, r.Name, r.Calls, want)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// record registers the close function and returns the wrapper which counts its calls
+// with the options which keep the close function name.
+func (reg *registry) record(f closer.CloseFn, opts []closer.AddOption) (closer.CloseFn, []closer.AddOption, *entry) {
+	name := closer.NameOf(f, opts...)
+	r := &entry{
+		Registration: Registration{Name: name},
+		lastRun:      -1,
+	}
+
+	reg.mu.Lock()
+	reg.entries = append(reg.entries, r)
+	reg.mu.Unlock()
+
+	wrapper := func(ctx context.Context) error {
+		reg.mu.Lock()
+		if r.lastRun != reg.run {
+			r.lastRun = reg.run
+			r.Calls++
+		}
+		reg.mu.Unlock()
+
+		return f(ctx)
+	}
+	return wrapper, append(opts[:len(opts):len(opts)], closer.WithName(name)), r
+}
+
+func (reg *registry) forget(r *entry) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for i := range reg.entries {
+		if reg.entries[i] == r {
+			reg.entries = append(reg.entries[:i], reg.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (reg *registry) onRemove(r *entry, remove func()) func() {
+	return func() {
+		reg.mu.Lock()
+		if r.Calls == 0 {
+			r.Removed = true
+		}
+		reg.mu.Unlock()
+
+		remove()
+	}
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closertest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/nbgrp/pkg/closer"
+	. "github.com/nbgrp/pkg/closer/closertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spyT records failures instead of failing the test.
+type spyT struct {
+	testing.TB
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (t *spyT) Helper() {}
+
+func (t *spyT) Error(args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func (t *spyT) Errorf(format string, args ...any) {
+	t.Error(fmt.Sprintf(format, args...))
+}
+
+func closeDB(context.Context) error {
+	return nil
+}
+
+func TestCloser(t *testing.T) {
+	t.Run("closed", func(t *testing.T) {
+		c := New(t)
+
+		var order []string
+		c.AddNamed("db", func(context.Context) error {
+			order = append(order, "db")
+			return nil
+		})
+		c.Child("http").Add(func(context.Context) error {
+			order = append(order, "http")
+			return nil
+		}, closer.WithName("http"))
+		remove := c.AddNamed("cache", func(context.Context) error {
+			order = append(order, "cache")
+			return nil
+		})
+		remove()
+
+		c.CloseAll()
+
+		assert.True(t, c.AssertClosed())
+		assert.Equal(t, []string{"http", "db"}, order)
+		assert.Equal(t, []Registration{
+			{Name: "db", Calls: 1},
+			{Name: "http", Calls: 1},
+			{Name: "cache", Removed: true},
+		}, c.Registrations())
+
+		_, err := c.TryAdd(func(context.Context) error { return nil })
+		require.ErrorIs(t, err, closer.ErrClosed)
+		assert.Len(t, c.Registrations(), 3)
+	})
+
+	t.Run("unnamed", func(t *testing.T) {
+		c := New(t)
+
+		c.Add(closeDB)
+		c.CloseAll()
+
+		const name = "github.com/nbgrp/pkg/closer/closertest_test.closeDB"
+		assert.Equal(t, []Registration{{Name: name, Calls: 1}}, c.Registrations())
+		require.Len(t, c.Report(), 1)
+		assert.Equal(t, name, c.Report()[0].Name)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		c := New(t)
+
+		attempts := 0
+		c.AddWithRetry(func(context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("transient error")
+			}
+			return nil
+		}, closer.RetryPolicy{MaxAttempts: 3}, closer.WithName("db"))
+
+		c.CloseAll()
+
+		require.NoError(t, c.Err())
+		assert.Equal(t, 3, attempts)
+		assert.True(t, c.AssertClosed())
+		assert.Equal(t, []Registration{{Name: "db", Calls: 1}}, c.Registrations())
+	})
+
+	t.Run("not closed", func(t *testing.T) {
+		spy := &spyT{TB: t}
+		c := New(spy)
+
+		c.AddNamed("db", func(context.Context) error { return nil })
+
+		assert.False(t, c.AssertClosed())
+		assert.Equal(t, []string{`close function #0 "db": called 0 times, want 1`}, spy.errors)
+	})
+
+	t.Run("goroutine leak", func(t *testing.T) {
+		spy := &spyT{TB: t}
+		c := New(spy)
+
+		release := make(chan struct{})
+		defer close(release)
+
+		c.Add(func(context.Context) error {
+			go func() {
+				<-release
+			}()
+			return nil
+		})
+
+		c.CloseAll()
+
+		require.Len(t, spy.errors, 1)
+		assert.Contains(t, spy.errors[0], "found unexpected goroutines")
+	})
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeoutError lists close functions which did not finish before their deadline.
+type TimeoutError struct {
+	Funcs []TimedOutFunc
+}
+
+// TimedOutFunc describes a close function which did not finish in time.
+type TimedOutFunc struct {
+	Name    string
+	Elapsed time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	var b strings.Builder
+	b.WriteString("close timeout:")
+	for i, f := range e.Funcs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(" " + f.Name + " (" + f.Elapsed.Round(time.Millisecond).String() + ")")
+	}
+	return b.String()
+}
+
+// PanicError holds the value of a close function panic and the stack of the panicking goroutine.
+// Both PanicError and derrors.PanicError have the PanicValue method, derrors.PanicValue matches either.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("close panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// PanicValue returns the panic value.
+func (e *PanicError) PanicValue() any {
+	return e.Value
+}
+
+// FuncError is an error of the named close function.
+type FuncError struct {
+	Name string
+	Err  error
+}
+
+func (e *FuncError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e *FuncError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Hooks observe the closer shutdown. Nil hooks are skipped.
+// The hooks receive the context passed into the close functions.
+type Hooks struct {
+	// OnCloseStart is called when CloseAll starts.
+	OnCloseStart func(ctx context.Context)
+	// OnFnStart is called before the close function runs.
+	OnFnStart func(ctx context.Context, name string)
+	// OnFnDone is called with the close function outcome.
+	OnFnDone func(ctx context.Context, res FuncReport)
+	// OnCloseDone is called when all close functions are done with the joint error.
+	OnCloseDone func(ctx context.Context, elapsed time.Duration, err error)
+}
+
+// SlogHooks returns hooks which log the closer shutdown events with the logger.
+// Failed close functions are logged at the error level, successful ones at the info level,
+// and starts of the close functions at the debug level.
+func SlogHooks(logger *slog.Logger) Hooks {
+	return Hooks{
+		OnCloseStart: func(ctx context.Context) {
+			logger.InfoContext(ctx, "shutdown started")
+		},
+		OnFnStart: func(ctx context.Context, name string) {
+			logger.DebugContext(ctx, "close function started", slog.String("name", name))
+		},
+		OnFnDone: func(ctx context.Context, res FuncReport) {
+			attrs := []slog.Attr{
+				slog.String("name", res.Name),
+				slog.Duration("duration", res.Duration),
+			}
+			switch {
+			case res.TimedOut:
+				logger.LogAttrs(ctx, slog.LevelError, "close function timed out", attrs...)
+			case res.Err != nil:
+				logger.LogAttrs(ctx, slog.LevelError, "close function failed", append(attrs, slog.Any("error", res.Err))...)
+			default:
+				logger.LogAttrs(ctx, slog.LevelInfo, "close function done", attrs...)
+			}
+		},
+		OnCloseDone: func(ctx context.Context, elapsed time.Duration, err error) {
+			if err != nil {
+				logger.ErrorContext(ctx, "shutdown done", slog.Duration("duration", elapsed), slog.Any("error", err))
+				return
+			}
+			logger.InfoContext(ctx, "shutdown done", slog.Duration("duration", elapsed))
+		},
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/nbgrp/pkg/closer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestHooks(t *testing.T) {
+	t.Run("events", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		var (
+			mu     sync.Mutex
+			events []string
+			done   []FuncReport
+		)
+		record := func(event string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}
+
+		var closeErr error
+		_, c := New(context.Background(), WithHooks(Hooks{
+			OnCloseStart: func(context.Context) {
+				record("close start")
+			},
+			OnFnStart: func(_ context.Context, name string) {
+				record("start " + name)
+			},
+			OnFnDone: func(_ context.Context, res FuncReport) {
+				record("done " + res.Name)
+				mu.Lock()
+				defer mu.Unlock()
+				done = append(done, res)
+			},
+			OnCloseDone: func(_ context.Context, elapsed time.Duration, err error) {
+				assert.Positive(t, elapsed)
+				closeErr = err
+				record("close done")
+			},
+		}))
+
+		errTest := errors.New("test error")
+		c.AddNamed("db", func(context.Context) error {
+			return errTest
+		})
+
+		c.CloseAll()
+
+		assert.Equal(t, []string{"close start", "start db", "done db", "close done"}, events)
+		require.Len(t, done, 1)
+		assert.ErrorIs(t, done[0].Err, errTest)
+		assert.ErrorIs(t, closeErr, errTest)
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		_, c := New(context.Background(), WithHooks(SlogHooks(logger)))
+		c.AddNamed("db", func(context.Context) error {
+			return errors.New("test error")
+		})
+		c.CloseAll()
+
+		out := buf.String()
+		assert.Contains(t, out, `level=INFO msg="shutdown started"`)
+		assert.Contains(t, out, `level=DEBUG msg="close function started" name=db`)
+		assert.Contains(t, out, `level=ERROR msg="close function failed" name=db`)
+		assert.Contains(t, out, `error="test error"`)
+		assert.Contains(t, out, `level=ERROR msg="shutdown done"`)
+	})
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Hook is a pair of functions which start and stop a component.
+type Hook struct {
+	// Name is used in the closer reports and errors.
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  CloseFn
+}
+
+// Lifecycle starts components in registration order and stops them in reverse order via the Closer.
+type Lifecycle struct {
+	closer       Closer
+	name         string
+	startTimeout time.Duration
+
+	mu    sync.Mutex
+	hooks []Hook
+}
+
+type LifecycleOption func(*Lifecycle)
+
+// WithStartTimeout limits time of every component start.
+func WithStartTimeout(timeout time.Duration) LifecycleOption {
+	return func(l *Lifecycle) {
+		l.startTimeout = timeout
+	}
+}
+
+// WithLifecycleName sets the name of the child closer which stops the components ("lifecycle" by default).
+func WithLifecycleName(name string) LifecycleOption {
+	return func(l *Lifecycle) {
+		l.name = name
+	}
+}
+
+// NewLifecycle returns Lifecycle which stops components via a child of the specified Closer.
+func NewLifecycle(c Closer, opts ...LifecycleOption) *Lifecycle {
+	l := &Lifecycle{name: "lifecycle"}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.closer = c.Child(l.name, WithMode(ModeLIFO))
+	return l
+}
+
+// Append registers the component hooks. Hooks appended after Start are not started.
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hooks = append(l.hooks, hook)
+}
+
+// Start runs OnStart hooks in registration order. When any of them fails, the already started
+// components are stopped and the start error is returned joined with the stop error.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := l.start(ctx, hook); err != nil {
+			l.closer.CloseAll() //nolint:contextcheck
+			return errors.Join(fmt.Errorf("start %s: %w", hook.Name, err), l.closer.Err())
+		}
+
+		if hook.OnStop != nil {
+			l.closer.Add(hook.OnStop, WithName(hook.Name))
+		}
+	}
+
+	return nil
+}
+
+// Stop stops the started components and returns their joint error.
+func (l *Lifecycle) Stop() error {
+	l.closer.CloseAll()
+	return l.closer.Err()
+}
+
+// Run starts the components, waits until the context is done or the Closer is closed,
+// and stops everything.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-l.closer.Done():
+	}
+
+	return l.Stop() //nolint:contextcheck
+}
+
+func (l *Lifecycle) start(ctx context.Context, hook Hook) error {
+	if hook.OnStart == nil {
+		return nil
+	}
+
+	if l.startTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.startTimeout)
+		defer cancel()
+	}
+
+	return hook.OnStart(ctx)
+}
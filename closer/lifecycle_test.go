@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/nbgrp/pkg/closer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Run("run", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		l := NewLifecycle(c)
+
+		r := &recorder{}
+		l.Append(r.hook("db", nil))
+		l.Append(r.hook("cache", nil))
+		l.Append(r.hook("http", nil))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- l.Run(ctx)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		require.NoError(t, <-errCh)
+
+		assert.Equal(t, []string{
+			"start db", "start cache", "start http",
+			"stop http", "stop cache", "stop db",
+		}, r.events)
+	})
+
+	t.Run("hook without stop", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		l := NewLifecycle(c)
+
+		r := &recorder{}
+		l.Append(r.hook("db", nil))
+		l.Append(Hook{Name: "metrics"})
+		l.Append(Hook{
+			Name: "http",
+			OnStop: func(context.Context) error {
+				time.Sleep(10 * time.Millisecond) // would finish after db if they ran concurrently
+				r.record("stop http")
+				return nil
+			},
+		})
+
+		require.NoError(t, l.Start(context.Background()))
+		require.NoError(t, l.Stop())
+
+		assert.Equal(t, []string{"start db", "stop http", "stop db"}, r.events)
+	})
+
+	t.Run("run until closer is closed", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		l := NewLifecycle(c)
+
+		r := &recorder{}
+		l.Append(r.hook("db", nil))
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- l.Run(context.Background())
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		c.CloseAll()
+		require.NoError(t, <-errCh)
+		assert.Equal(t, []string{"start db", "stop db"}, r.events)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		l := NewLifecycle(c)
+
+		errStart := errors.New("start error")
+		r := &recorder{}
+		l.Append(r.hook("db", nil))
+		l.Append(r.hook("cache", nil))
+		l.Append(r.hook("http", errStart))
+		l.Append(r.hook("grpc", nil))
+
+		err := l.Start(context.Background())
+		require.ErrorIs(t, err, errStart)
+		assert.ErrorContains(t, err, "start http: start error")
+
+		assert.Equal(t, []string{
+			"start db", "start cache", "start http",
+			"stop cache", "stop db",
+		}, r.events)
+	})
+
+	t.Run("start timeout", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		l := NewLifecycle(c, WithStartTimeout(10*time.Millisecond))
+
+		l.Append(Hook{
+			Name: "slow",
+			OnStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+
+		require.ErrorIs(t, l.Start(context.Background()), context.DeadlineExceeded)
+	})
+
+	t.Run("name", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+		l := NewLifecycle(c, WithLifecycleName("components"))
+
+		errStop := errors.New("stop error")
+		l.Append(Hook{
+			Name:   "db",
+			OnStop: func(context.Context) error { return errStop },
+		})
+		require.NoError(t, l.Start(context.Background()))
+
+		c.CloseAll()
+
+		errs := c.Errors()
+		require.Len(t, errs, 1)
+		var funcErr *FuncError
+		require.ErrorAs(t, errs[0], &funcErr)
+		assert.Equal(t, "components", funcErr.Name)
+		assert.ErrorIs(t, errs[0], errStop)
+	})
+}
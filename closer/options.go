@@ -4,15 +4,70 @@ package closer
 
 import (
 	"os"
+	"time"
 )
 
 type options struct {
 	signals   []os.Signal
 	ctxCancel bool
+	timeout   time.Duration
+	repanic   bool
+	escalate  bool
+	grace     time.Duration
+	hardExit  func()
+
+	latePolicy LatePolicy
+	hooks      Hooks
+
+	mode           Mode
+	maxConcurrency int
+	stopOnError    bool
+
+	drainDelay time.Duration
+
+	signalHandlers []signalHandler
+}
+
+type signalHandler struct {
+	handler SignalHandler
+	signals []os.Signal
+}
+
+// notifySignals returns all signals the closer should be notified about.
+func (o options) notifySignals() []os.Signal {
+	signals := append([]os.Signal(nil), o.signals...)
+	for _, h := range o.signalHandlers {
+		signals = append(signals, h.signals...)
+	}
+	return signals
+}
+
+// signalHandler returns the custom handler of the signal if any.
+func (o options) signalHandler(sig os.Signal) SignalHandler {
+	for _, h := range o.signalHandlers {
+		for _, s := range h.signals {
+			if s == sig {
+				return h.handler
+			}
+		}
+	}
+	return nil
 }
 
 type Option func(options) options
 
+// Mode defines how close functions of a phase run.
+type Mode int
+
+const (
+	// ModeConcurrent runs close functions of a phase concurrently.
+	ModeConcurrent Mode = iota
+	// ModeLIFO runs close functions of a phase one by one in reverse registration order, like defer.
+	// The registration order is the order in which Add calls take effect, so close functions
+	// added by the same goroutine are closed in reverse order of their Add calls.
+	ModeLIFO
+)
+
 // WithContextCancel allows to call closer CloseAll on context cancel implicitly.
 func WithContextCancel() Option {
 	return func(o options) options {
@@ -30,3 +85,158 @@ func WithSignals(signals ...os.Signal) Option {
 		return o
 	}
 }
+
+// WithTimeout limits the total time CloseAll waits for close functions.
+// The context passed into the close functions expires after the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o options) options {
+		o.timeout = timeout
+		return o
+	}
+}
+
+// WithRepanic makes CloseAll panic with the first PanicError after all close functions complete.
+func WithRepanic() Option {
+	return func(o options) options {
+		o.repanic = true
+		return o
+	}
+}
+
+// WithEscalation enables forceful shutdown after the graceful one.
+// The context passed into the close functions is canceled on the second signal (see WithSignals)
+// or when the grace period elapses after CloseAll starts, if the grace period is positive.
+// The hard exit hook, if non-nil, is called on the third and every subsequent signal.
+func WithEscalation(grace time.Duration, hardExit func()) Option {
+	return func(o options) options {
+		o.escalate = true
+		o.grace = grace
+		o.hardExit = hardExit
+		return o
+	}
+}
+
+// LatePolicy defines how Add handles close functions added after CloseAll started.
+type LatePolicy int
+
+const (
+	// LateRun runs the close function immediately with the shutdown context
+	// (without cancel once CloseAll is done). Its outcome is appended into the closer report.
+	LateRun LatePolicy = iota
+	// LatePanic makes Add panic with ErrClosed.
+	LatePanic
+)
+
+// WithLatePolicy sets the policy for close functions added after CloseAll started (LateRun by default).
+func WithLatePolicy(policy LatePolicy) Option {
+	return func(o options) options {
+		o.latePolicy = policy
+		return o
+	}
+}
+
+// WithHooks sets hooks which observe the closer shutdown.
+func WithHooks(hooks Hooks) Option {
+	return func(o options) options {
+		o.hooks = hooks
+		return o
+	}
+}
+
+// WithMode sets the mode of close functions running within a phase (ModeConcurrent by default).
+func WithMode(mode Mode) Option {
+	return func(o options) options {
+		o.mode = mode
+		return o
+	}
+}
+
+// WithMaxConcurrency limits the number of simultaneously running close functions in ModeConcurrent.
+func WithMaxConcurrency(n int) Option {
+	return func(o options) options {
+		o.maxConcurrency = n
+		return o
+	}
+}
+
+// WithStopOnError makes CloseAll skip the rest of close functions after the first failed one
+// (returned an error, panicked or timed out). In ModeLIFO it stops right after the failure,
+// in ModeConcurrent the phases following the failed one are skipped.
+func WithStopOnError() Option {
+	return func(o options) options {
+		o.stopOnError = true
+		return o
+	}
+}
+
+// WithDrainDelay makes CloseAll wait for the delay after the closer state becomes StateDraining
+// and before close functions start, e.g. to let load balancers deregister the instance.
+// The delay is interrupted when the shutdown escalates (see WithEscalation).
+func WithDrainDelay(delay time.Duration) Option {
+	return func(o options) options {
+		o.drainDelay = delay
+		return o
+	}
+}
+
+// SignalHandler handles a signal which does not close the closer.
+type SignalHandler func(c Closer, sig os.Signal)
+
+// WithSignalHandler makes the closer call the handler when any of the specified signals arrives
+// until the closer is done, e.g. to reload configuration on SIGHUP.
+// Every call runs in its own goroutine, so a slow handler does not block the shutdown signals
+// and the handler may run concurrently with itself on repeated signals.
+// The handlers take precedence over the signals of WithSignals.
+func WithSignalHandler(handler SignalHandler, signals ...os.Signal) Option {
+	return func(o options) options {
+		o.signalHandlers = append(o.signalHandlers, signalHandler{
+			handler: handler,
+			signals: signals,
+		})
+		return o
+	}
+}
+
+type addOptions struct {
+	name    string
+	phase   string
+	timeout time.Duration
+	retry   *RetryPolicy
+}
+
+type AddOption func(addOptions) addOptions
+
+// InPhase puts the close function into the specified phase (see Closer.DeclarePhase).
+// Close functions without a phase belong to the unnamed phase "".
+func InPhase(phase string) AddOption {
+	return func(o addOptions) addOptions {
+		o.phase = phase
+		return o
+	}
+}
+
+// WithFuncTimeout limits the time CloseAll waits for the close function.
+// The context passed into the close function expires after the timeout.
+func WithFuncTimeout(timeout time.Duration) AddOption {
+	return func(o addOptions) addOptions {
+		o.timeout = timeout
+		return o
+	}
+}
+
+// WithName sets the name used in the closer reports and errors.
+// Close functions without a name are reported by the function name.
+func WithName(name string) AddOption {
+	return func(o addOptions) addOptions {
+		o.name = name
+		return o
+	}
+}
+
+// WithRetry makes CloseAll retry the close function on failure according to the policy.
+func WithRetry(policy RetryPolicy) AddOption {
+	return func(o addOptions) addOptions {
+		o.retry = &policy
+		return o
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrPhaseCycle is returned when a phase declaration produces a dependency cycle.
+var ErrPhaseCycle = errors.New("phase dependency cycle")
+
+// phaseGraph maps phase name to the names of phases it depends on.
+type phaseGraph map[string][]string
+
+func (c *closer) DeclarePhase(name string, dependsOn ...string) error {
+	for {
+		old := c.phases.Load()
+
+		graph := make(phaseGraph)
+		if old != nil {
+			for phase, deps := range *old {
+				graph[phase] = deps
+			}
+		}
+		graph[name] = append(append([]string(nil), graph[name]...), dependsOn...)
+
+		if cycle := graph.findCycle(name); cycle != nil {
+			return fmt.Errorf("%w: %s", ErrPhaseCycle, strings.Join(cycle, " -> "))
+		}
+
+		if c.phases.CompareAndSwap(old, &graph) {
+			return nil
+		}
+	}
+}
+
+// findCycle returns the dependency path which leads from the phase back to itself, if any.
+func (g phaseGraph) findCycle(phase string) []string {
+	visited := make(map[string]bool)
+
+	var walk func(path []string) []string
+	walk = func(path []string) []string {
+		for _, dep := range g[path[len(path)-1]] {
+			if dep == phase {
+				return append(path, dep)
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if cycle := walk(append(path, dep)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	return walk([]string{phase})
+}
+
+// closeOrder splits close functions into waves: each wave contains close functions of the phases
+// whose dependents are all closed by the previous waves. Waves keep the registration order.
+func (g phaseGraph) closeOrder(funcs []*entry) [][]*entry {
+	dependents := g.countDependents()
+	for _, e := range funcs {
+		dependents[e.phase] += 0
+	}
+
+	var waves [][]*entry
+	for len(dependents) > 0 {
+		ready := make(map[string]bool)
+		for _, phase := range g.popReady(dependents) {
+			ready[phase] = true
+		}
+
+		var wave []*entry
+		for _, e := range funcs {
+			if ready[e.phase] {
+				wave = append(wave, e)
+			}
+		}
+		if len(wave) > 0 {
+			waves = append(waves, wave)
+		}
+	}
+
+	return waves
+}
+
+func (g phaseGraph) countDependents() map[string]int {
+	dependents := make(map[string]int, len(g))
+	for phase, deps := range g {
+		dependents[phase] += 0
+		for _, dep := range deps {
+			dependents[dep]++
+		}
+	}
+	return dependents
+}
+
+// popReady removes phases without pending dependents and releases their dependencies.
+func (g phaseGraph) popReady(dependents map[string]int) []string {
+	var ready []string
+	for phase, n := range dependents {
+		if n == 0 {
+			ready = append(ready, phase)
+		}
+	}
+	sort.Strings(ready)
+
+	for _, phase := range ready {
+		delete(dependents, phase)
+		for _, dep := range g[phase] {
+			dependents[dep]--
+		}
+	}
+	return ready
+}
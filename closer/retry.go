@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy defines how a failed close function is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of the close function calls including the first one.
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, it doubles on every next attempt.
+	Backoff time.Duration
+	// MaxBackoff limits the delay between attempts if positive.
+	MaxBackoff time.Duration
+}
+
+// callWithRetry calls the close function until it succeeds, the attempts are exhausted, or
+// the next delay does not fit into the context deadline. If all the attempts fail, the errors
+// of every attempt are joined.
+func (e *entry) callWithRetry(ctx context.Context) error {
+	if e.retry == nil {
+		return e.call(ctx)
+	}
+
+	var errs []error
+	delay := e.retry.capped(e.retry.Backoff)
+	for attempt := 1; ; attempt++ {
+		err := e.call(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("attempt %d: %w", attempt, err))
+
+		if attempt >= e.retry.MaxAttempts || !sleep(ctx, delay) {
+			return errors.Join(errs...)
+		}
+
+		delay = e.retry.capped(delay * 2)
+	}
+}
+
+func (p *RetryPolicy) capped(delay time.Duration) time.Duration {
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// sleep waits for the delay and reports false if the context deadline comes earlier
+// or the context is done.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/nbgrp/pkg/closer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("transient error")
+
+	t.Run("success after failures", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+
+		var attempts int
+		c.AddWithRetry(func(context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errTransient
+			}
+			return nil
+		}, RetryPolicy{MaxAttempts: 5, Backoff: time.Millisecond})
+
+		c.CloseAll()
+
+		require.NoError(t, c.Err())
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background())
+
+		var attempts int
+		c.AddWithRetry(func(context.Context) error {
+			attempts++
+			return errTransient
+		}, RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})
+
+		c.CloseAll()
+
+		err := c.Err()
+		require.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 3, attempts)
+		for _, msg := range []string{"attempt 1: ", "attempt 2: ", "attempt 3: "} {
+			assert.ErrorContains(t, err, msg+"transient error")
+		}
+	})
+
+	t.Run("bounded by deadline", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithTimeout(time.Second))
+
+		var attempts int
+		c.AddWithRetry(func(context.Context) error {
+			attempts++
+			return errTransient
+		}, RetryPolicy{MaxAttempts: 10, Backoff: 400 * time.Millisecond})
+
+		start := time.Now()
+		c.CloseAll()
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, 2, attempts)
+		require.ErrorContains(t, c.Err(), "attempt 2: transient error")
+
+		var timeoutErr *TimeoutError
+		assert.False(t, errors.As(c.Err(), &timeoutErr))
+	})
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+	"sync"
+)
+
+// DumpHandler returns signal handler which dumps the closer into w (see Closer.Dump).
+func DumpHandler(w io.Writer) SignalHandler {
+	return func(c Closer, _ os.Signal) {
+		_ = c.Dump(w)
+	}
+}
+
+func (c *closer) Dump(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "closer state: %s\nclose functions:\n", c.State()); err != nil {
+		return err
+	}
+
+	if funcs := c.funcs.Load(); funcs != nil {
+		for _, e := range *funcs {
+			if e.removed.Load() {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "\t%s (phase %q)\n", e.name, e.phase); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, "goroutines:\n"); err != nil {
+		return err
+	}
+	return pprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// watchSignals handles the signals until the closer is done: the custom handlers are called
+// for their signals, the rest of signals cancel the closer context and escalate the shutdown.
+// The custom handlers run in their own goroutines, so a slow handler does not delay the shutdown.
+func (c *closer) watchSignals(sigCh chan os.Signal, cancel context.CancelFunc) {
+	defer cancel()
+	defer signal.Stop(sigCh)
+
+	var handlers sync.WaitGroup
+	defer handlers.Wait()
+
+	var n int
+	for {
+		var sig os.Signal
+		select {
+		case sig = <-sigCh:
+		case <-c.done:
+			return
+		}
+
+		if handler := c.opts.signalHandler(sig); handler != nil {
+			handlers.Add(1)
+			go func() {
+				defer handlers.Done()
+				handler(c, sig)
+			}()
+			continue
+		}
+
+		n++
+		c.escalate(n, cancel)
+	}
+}
+
+// escalate cancels the closer context on the first signal and escalates the shutdown
+// on the subsequent ones.
+func (c *closer) escalate(n int, cancel context.CancelFunc) {
+	switch {
+	case n == 1:
+		cancel()
+	case !c.opts.escalate:
+		// repeated signals are ignored without escalation
+	case n == 2:
+		c.abort()
+	case c.opts.hardExit != nil:
+		c.opts.hardExit()
+	}
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// State is a closer state.
+type State int32
+
+const (
+	// StateRunning is the state of the closer before CloseAll.
+	StateRunning State = iota
+	// StateDraining is the state of the closer while CloseAll runs.
+	StateDraining
+	// StateClosed is the state of the closer after CloseAll is done.
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateRunning:
+		return "running"
+	case StateDraining:
+		return "draining"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+func (c *closer) State() State {
+	return State(c.state.Load())
+}
+
+// ReadinessHandler returns handler which responds with 200 OK while the closer is running
+// and with 503 Service Unavailable once the shutdown starts.
+func ReadinessHandler(c Closer) http.Handler {
+	return stateHandler(c, StateRunning)
+}
+
+// LivenessHandler returns handler which responds with 200 OK until the closer is closed
+// and with 503 Service Unavailable afterward.
+func LivenessHandler(c Closer) http.Handler {
+	return stateHandler(c, StateDraining)
+}
+
+// stateHandler responds with 200 OK while the closer state is not after the last healthy one.
+func stateHandler(c Closer, lastHealthy State) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		state := c.State()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if state > lastHealthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write([]byte(state.String()))
+	})
+}
+
+func waitDrainDelay(ctx context.Context, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package closer_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/nbgrp/pkg/closer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func probe(t *testing.T, h http.Handler) (int, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	return rec.Code, rec.Body.String()
+}
+
+func TestState(t *testing.T) {
+	t.Run("drain", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(), WithDrainDelay(50*time.Millisecond))
+		readiness, liveness := ReadinessHandler(c), LivenessHandler(c)
+
+		var closed atomic.Bool
+		c.Add(func(context.Context) error {
+			closed.Store(true)
+			return nil
+		})
+
+		assert.Equal(t, StateRunning, c.State())
+		code, body := probe(t, readiness)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "running", body)
+
+		go c.CloseAll()
+
+		require.Eventually(t, func() bool {
+			return c.State() == StateDraining
+		}, time.Second, time.Millisecond)
+		assert.False(t, closed.Load())
+
+		code, body = probe(t, readiness)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "draining", body)
+		code, _ = probe(t, liveness)
+		assert.Equal(t, http.StatusOK, code)
+
+		<-c.Done()
+		assert.True(t, closed.Load())
+		assert.Equal(t, StateClosed, c.State())
+
+		code, body = probe(t, liveness)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "closed", body)
+	})
+
+	t.Run("drain delay interrupted by escalation", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		_, c := New(context.Background(),
+			WithDrainDelay(time.Minute),
+			WithEscalation(20*time.Millisecond, nil),
+		)
+
+		start := time.Now()
+		c.CloseAll()
+		assert.Less(t, time.Since(start), time.Minute)
+	})
+}
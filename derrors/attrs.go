@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type attrError struct {
+	err   error
+	attrs []slog.Attr
+}
+
+// With attaches structured attributes to the error. The args are key-value pairs
+// or slog.Attr values, like the args of slog.Logger.Info. It returns nil for nil error.
+//
+// The attributes survive wrapping and Join, and they are logged along with the error message
+// when the error is passed to slog as a value of LogValue.
+func With(err error, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &attrError{
+		err:   err,
+		attrs: slog.Group("", args...).Value.Group(),
+	}
+}
+
+func (e *attrError) Error() string {
+	return e.err.Error()
+}
+
+func (e *attrError) Unwrap() error {
+	return e.err
+}
+
+// LogValue returns the slog value of the error (see LogValue).
+func (e *attrError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// Format formats the error keeping %+v formatting of the wrapped error.
+func (e *attrError) Format(f fmt.State, verb rune) {
+	formatError(f, verb, e.err)
+}
+
+// Attrs returns the attributes attached to the error and the errors it wraps or joins.
+// The attributes of outer errors go first.
+func Attrs(err error) []slog.Attr {
+	var attrs []slog.Attr
+	Walk(err, func(err error) bool {
+		if e, ok := err.(*attrError); ok { //nolint:errorlint // the tree is traversed by Walk
+			attrs = append(attrs, e.attrs...)
+		}
+		return true
+	})
+	return attrs
+}
+
+// LogValue returns the slog value of the error: a group of the error message with the "msg" key
+// and the attributes of the error tree (see Attrs), or just the error message if there are
+// no attributes. Use it to log errors which are wrapped by fmt.Errorf or other packages:
+//
+//	logger.Error("request failed", slog.Any("err", derrors.LogValue(err)))
+func LogValue(err error) slog.Value {
+	if err == nil {
+		return slog.Value{}
+	}
+
+	attrs := Attrs(err)
+	if len(attrs) == 0 {
+		return slog.StringValue(err.Error())
+	}
+	return slog.GroupValue(append([]slog.Attr{slog.String("msg", err.Error())}, attrs...)...)
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	. "github.com/nbgrp/pkg/derrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWith(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		require.NoError(t, With(nil, "user_id", 42))
+		assert.Equal(t, slog.Value{}, LogValue(nil))
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := fmt.Errorf("handle: %w", With(errExternal, "user_id", 42, slog.String("method", "GET")))
+
+		require.ErrorIs(t, err, errExternal)
+		assert.EqualError(t, err, "handle: external")
+		assert.Equal(t, []slog.Attr{slog.Int("user_id", 42), slog.String("method", "GET")}, Attrs(err))
+	})
+
+	t.Run("outer attributes first", func(t *testing.T) {
+		err := With(fmt.Errorf("handle: %w", With(errExternal, "user_id", 42)), "request_id", "abc")
+
+		assert.Equal(t, []slog.Attr{slog.String("request_id", "abc"), slog.Int("user_id", 42)}, Attrs(err))
+	})
+
+	t.Run("joined", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Join(&err, With(errExternal, "close", "db"))
+			return With(errInternal, "user_id", 42)
+		}
+
+		assert.Equal(t, []slog.Attr{slog.Int("user_id", 42), slog.String("close", "db")}, Attrs(fn()))
+	})
+
+	t.Run("without attributes", func(t *testing.T) {
+		assert.Empty(t, Attrs(errExternal))
+		assert.Equal(t, slog.StringValue("external"), LogValue(errExternal))
+	})
+}
+
+func TestLogValue(t *testing.T) {
+	log := func(t *testing.T, arg any) map[string]any {
+		t.Helper()
+
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", "err", arg)
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		return record
+	}
+
+	t.Run("log valuer", func(t *testing.T) {
+		err := WithCode(With(errExternal, "user_id", 42), NotFound)
+
+		assert.Equal(t, map[string]any{"msg": "external", "user_id": float64(42)}, log(t, err)["err"])
+	})
+
+	t.Run("joined", func(t *testing.T) {
+		var err error = With(errInternal, "user_id", 42)
+		Join(&err, errExternal)
+
+		assert.Equal(t, map[string]any{"msg": "internal\nexternal", "user_id": float64(42)}, log(t, err)["err"])
+	})
+
+	t.Run("wrapped by fmt", func(t *testing.T) {
+		err := fmt.Errorf("handle: %w", With(errExternal, "user_id", 42))
+
+		assert.Equal(t, "handle: external", log(t, err)["err"])
+		assert.Equal(t, map[string]any{"msg": "handle: external", "user_id": float64(42)}, log(t, LogValue(err))["err"])
+	})
+}
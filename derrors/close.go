@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors
+
+import (
+	"context"
+	"io"
+	"reflect"
+)
+
+// Close closes c and joins the close error into the error (see Join). It is intended to be deferred:
+//
+//	func Read(name string) (_ []byte, err error) {
+//		f, err := os.Open(name)
+//		if err != nil {
+//			return nil, err
+//		}
+//		defer derrors.Close(&err, f)
+//		...
+//	}
+//
+// Nil c (including a typed nil pointer) is ignored. If err is nil, c is closed anyway
+// and the close error is discarded.
+func Close(err *error, c io.Closer) { //nolint:gocritic // ptrToRefParam here is OK
+	if isNil(c) {
+		return
+	}
+	Join(err, c.Close())
+}
+
+// CloseCtx is like Close but calls the close function with the context,
+// e.g. closer.CloseFn or http.Server.Shutdown. Nil fn is ignored.
+func CloseCtx(err *error, ctx context.Context, fn func(context.Context) error) { //nolint:gocritic // ptrToRefParam here is OK
+	if fn == nil {
+		return
+	}
+	Join(err, fn(ctx))
+}
+
+func isNil(c io.Closer) bool {
+	if c == nil {
+		return true
+	}
+	v := reflect.ValueOf(c)
+	switch v.Kind() { //nolint:exhaustive // only nillable kinds are checked
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
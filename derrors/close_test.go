@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/nbgrp/pkg/derrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type closerFunc func() error
+
+func (fn closerFunc) Close() error {
+	return fn()
+}
+
+type nilCloser struct{}
+
+func (*nilCloser) Close() error {
+	panic("unexpected call")
+}
+
+func TestClose(t *testing.T) {
+	failing := closerFunc(func() error { return errExternal })
+
+	t.Run("close error", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Close(&err, failing)
+			return nil
+		}
+
+		require.ErrorIs(t, fn(), errExternal)
+	})
+
+	t.Run("joined", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Close(&err, failing)
+			return errInternal
+		}
+
+		err := fn()
+		require.ErrorIs(t, err, errInternal)
+		require.ErrorIs(t, err, errExternal)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		fn := func(name string) (_ []byte, err error) {
+			f, err := os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			defer Close(&err, f)
+			return io.ReadAll(f)
+		}
+
+		name := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(name, []byte("data"), 0o600))
+
+		data, err := fn(name)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data"), data)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var err error
+		Close(&err, nil)
+		Close(&err, (*nilCloser)(nil))
+		Close(&err, (*os.File)(nil))
+		require.NoError(t, err)
+
+		closed := false
+		Close(nil, closerFunc(func() error {
+			closed = true
+			return errExternal
+		}))
+		assert.True(t, closed)
+	})
+}
+
+func TestCloseCtx(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	fn := func() (err error) {
+		defer CloseCtx(&err, ctx, func(ctx context.Context) error {
+			assert.Equal(t, "value", ctx.Value(ctxKey{}))
+			return errExternal
+		})
+		return errInternal
+	}
+
+	err := fn()
+	require.ErrorIs(t, err, errInternal)
+	require.ErrorIs(t, err, errExternal)
+
+	t.Run("nil", func(t *testing.T) {
+		var err error
+		CloseCtx(&err, ctx, nil)
+		require.NoError(t, err)
+
+		closed := false
+		CloseCtx(nil, ctx, func(context.Context) error {
+			closed = true
+			return errExternal
+		})
+		assert.True(t, closed)
+	})
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// Code classifies errors. The codes and their numeric values match gRPC status codes.
+type Code uint32
+
+const (
+	// OK is the code of nil error.
+	OK Code = iota
+	Canceled
+	// Unknown is the code of errors without an attached code.
+	Unknown
+	InvalidArgument
+	DeadlineExceeded
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	ResourceExhausted
+	FailedPrecondition
+	Aborted
+	OutOfRange
+	Unimplemented
+	Internal
+	Unavailable
+	DataLoss
+	Unauthenticated
+)
+
+var codeNames = [...]string{
+	OK:                 "ok",
+	Canceled:           "canceled",
+	Unknown:            "unknown",
+	InvalidArgument:    "invalid argument",
+	DeadlineExceeded:   "deadline exceeded",
+	NotFound:           "not found",
+	AlreadyExists:      "already exists",
+	PermissionDenied:   "permission denied",
+	ResourceExhausted:  "resource exhausted",
+	FailedPrecondition: "failed precondition",
+	Aborted:            "aborted",
+	OutOfRange:         "out of range",
+	Unimplemented:      "unimplemented",
+	Internal:           "internal",
+	Unavailable:        "unavailable",
+	DataLoss:           "data loss",
+	Unauthenticated:    "unauthenticated",
+}
+
+var httpStatuses = [...]int{
+	OK:                 http.StatusOK,
+	Canceled:           499, // client closed request
+	Unknown:            http.StatusInternalServerError,
+	InvalidArgument:    http.StatusBadRequest,
+	DeadlineExceeded:   http.StatusGatewayTimeout,
+	NotFound:           http.StatusNotFound,
+	AlreadyExists:      http.StatusConflict,
+	PermissionDenied:   http.StatusForbidden,
+	ResourceExhausted:  http.StatusTooManyRequests,
+	FailedPrecondition: http.StatusBadRequest,
+	Aborted:            http.StatusConflict,
+	OutOfRange:         http.StatusBadRequest,
+	Unimplemented:      http.StatusNotImplemented,
+	Internal:           http.StatusInternalServerError,
+	Unavailable:        http.StatusServiceUnavailable,
+	DataLoss:           http.StatusInternalServerError,
+	Unauthenticated:    http.StatusUnauthorized,
+}
+
+func (c Code) String() string {
+	if int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return "code(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// HTTPStatus returns HTTP status code corresponding to the code.
+// Unknown codes correspond to 500 Internal Server Error.
+func (c Code) HTTPStatus() int {
+	if int(c) < len(httpStatuses) {
+		return httpStatuses[c]
+	}
+	return http.StatusInternalServerError
+}
+
+type codeError struct {
+	err  error
+	code Code
+}
+
+// WithCode attaches the code to the error. It returns nil for nil error.
+func WithCode(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+	return &codeError{
+		err:  err,
+		code: code,
+	}
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+// LogValue returns the slog value of the error (see LogValue).
+func (e *codeError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// Format formats the error keeping %+v formatting of the wrapped error.
+func (e *codeError) Format(f fmt.State, verb rune) {
+	formatError(f, verb, e.err)
+}
+
+// CodeOf returns the code attached to the error. Wrapped and joined errors are traversed
+// depth-first and the first attached code is returned. Context cancellation and deadline errors
+// without an attached code are classified as Canceled and DeadlineExceeded, other errors
+// without a code are Unknown. CodeOf returns OK for nil error.
+func CodeOf(err error) Code {
+	var codeErr *codeError
+	switch {
+	case err == nil:
+		return OK
+	case errors.As(err, &codeErr):
+		return codeErr.code
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return DeadlineExceeded
+	default:
+		return Unknown
+	}
+}
+
+// HTTPStatusOf returns HTTP status code corresponding to the error code (see CodeOf).
+func HTTPStatusOf(err error) int {
+	return CodeOf(err).HTTPStatus()
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/nbgrp/pkg/derrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOf(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.Equal(t, OK, CodeOf(nil))
+		require.NoError(t, WithCode(nil, NotFound))
+	})
+
+	t.Run("without code", func(t *testing.T) {
+		assert.Equal(t, Unknown, CodeOf(errExternal))
+	})
+
+	t.Run("context errors", func(t *testing.T) {
+		assert.Equal(t, Canceled, CodeOf(fmt.Errorf("op: %w", context.Canceled)))
+		assert.Equal(t, DeadlineExceeded, CodeOf(context.DeadlineExceeded))
+		assert.Equal(t, Unavailable, CodeOf(WithCode(context.Canceled, Unavailable)))
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := fmt.Errorf("op: %w", WithCode(errExternal, NotFound))
+
+		assert.Equal(t, NotFound, CodeOf(err))
+		assert.ErrorIs(t, err, errExternal)
+		assert.EqualError(t, err, "op: external")
+	})
+
+	t.Run("stack", func(t *testing.T) {
+		err := WithCode(New("boom"), NotFound)
+
+		assert.Equal(t, "boom", fmt.Sprintf("%v", err))
+		assert.Regexp(t, `^boom\n.+derrors_test\.TestCodeOf`, fmt.Sprintf("%+v", err))
+	})
+
+	t.Run("outermost code wins", func(t *testing.T) {
+		err := WithCode(fmt.Errorf("op: %w", WithCode(errExternal, NotFound)), Internal)
+
+		assert.Equal(t, Internal, CodeOf(err))
+	})
+
+	t.Run("joined", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Join(&err, WithCode(errExternal, Unavailable))
+			return errInternal
+		}
+
+		assert.Equal(t, Unavailable, CodeOf(fn()))
+		assert.Equal(t, InvalidArgument, CodeOf(errors.Join(errInternal, WithCode(errExternal, InvalidArgument))))
+	})
+}
+
+func TestCode_String(t *testing.T) {
+	assert.Equal(t, "not found", NotFound.String())
+	assert.Equal(t, "unauthenticated", Unauthenticated.String())
+	assert.Equal(t, "code(42)", Code(42).String())
+}
+
+func TestCode_HTTPStatus(t *testing.T) {
+	tests := map[Code]int{
+		OK:                http.StatusOK,
+		Canceled:          499,
+		Unknown:           http.StatusInternalServerError,
+		InvalidArgument:   http.StatusBadRequest,
+		DeadlineExceeded:  http.StatusGatewayTimeout,
+		NotFound:          http.StatusNotFound,
+		AlreadyExists:     http.StatusConflict,
+		PermissionDenied:  http.StatusForbidden,
+		ResourceExhausted: http.StatusTooManyRequests,
+		Unimplemented:     http.StatusNotImplemented,
+		Unavailable:       http.StatusServiceUnavailable,
+		Unauthenticated:   http.StatusUnauthorized,
+		Code(42):          http.StatusInternalServerError,
+	}
+	for code, status := range tests {
+		assert.Equal(t, status, code.HTTPStatus(), code.String())
+	}
+
+	assert.Equal(t, http.StatusNotFound, HTTPStatusOf(WithCode(errExternal, NotFound)))
+	assert.Equal(t, http.StatusOK, HTTPStatusOf(nil))
+}
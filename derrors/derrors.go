@@ -3,12 +3,68 @@
 package derrors
 
 import (
-	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
 )
 
 func Join(err *error, errs ...error) { //nolint:gocritic // ptrToRefParam here is OK
 	if err == nil {
 		return
 	}
-	*err = errors.Join(append([]error{*err}, errs...)...)
+	*err = join(append([]error{*err}, errs...)...)
+}
+
+// joinError is like the errors.Join error, but it keeps %+v formatting of the joined errors,
+// e.g. their stacks.
+type joinError struct {
+	errs []error
+}
+
+func join(errs ...error) error {
+	nonNil := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	return &joinError{errs: nonNil}
+}
+
+func (e *joinError) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *joinError) Unwrap() []error {
+	return e.errs
+}
+
+// LogValue returns the slog value of the error (see LogValue).
+func (e *joinError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// Format formats the error with %+v as the joined errors formatted with %+v on separate lines.
+func (e *joinError) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		for i, err := range e.errs {
+			if i > 0 {
+				_, _ = io.WriteString(f, "\n")
+			}
+			_, _ = fmt.Fprintf(f, "%+v", err)
+		}
+	case verb == 'q':
+		_, _ = fmt.Fprintf(f, "%q", e.Error())
+	default:
+		_, _ = io.WriteString(f, e.Error())
+	}
 }
@@ -0,0 +1,16 @@
+module github.com/nbgrp/pkg/derrors/grpccodes
+
+go 1.21
+
+require (
+	github.com/nbgrp/pkg/derrors v0.1.0
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/grpc v1.64.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
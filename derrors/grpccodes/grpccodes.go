@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package grpccodes maps derrors codes to gRPC status codes.
+// It is a separate module, so derrors users do not get gRPC in their module graph.
+package grpccodes
+
+import (
+	"github.com/nbgrp/pkg/derrors"
+	"google.golang.org/grpc/codes"
+)
+
+// FromCode returns gRPC status code corresponding to the code.
+func FromCode(code derrors.Code) codes.Code {
+	// derrors codes share numeric values with gRPC codes.
+	return codes.Code(code)
+}
+
+// FromError returns gRPC status code corresponding to the error code (see derrors.CodeOf).
+func FromError(err error) codes.Code {
+	return FromCode(derrors.CodeOf(err))
+}
+
+// ToCode returns derrors code corresponding to gRPC status code.
+func ToCode(code codes.Code) derrors.Code {
+	return derrors.Code(code)
+}
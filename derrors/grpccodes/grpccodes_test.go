@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package grpccodes_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nbgrp/pkg/derrors"
+	. "github.com/nbgrp/pkg/derrors/grpccodes"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestFromCode(t *testing.T) {
+	tests := map[derrors.Code]codes.Code{
+		derrors.OK:                 codes.OK,
+		derrors.Canceled:           codes.Canceled,
+		derrors.Unknown:            codes.Unknown,
+		derrors.InvalidArgument:    codes.InvalidArgument,
+		derrors.DeadlineExceeded:   codes.DeadlineExceeded,
+		derrors.NotFound:           codes.NotFound,
+		derrors.AlreadyExists:      codes.AlreadyExists,
+		derrors.PermissionDenied:   codes.PermissionDenied,
+		derrors.ResourceExhausted:  codes.ResourceExhausted,
+		derrors.FailedPrecondition: codes.FailedPrecondition,
+		derrors.Aborted:            codes.Aborted,
+		derrors.OutOfRange:         codes.OutOfRange,
+		derrors.Unimplemented:      codes.Unimplemented,
+		derrors.Internal:           codes.Internal,
+		derrors.Unavailable:        codes.Unavailable,
+		derrors.DataLoss:           codes.DataLoss,
+		derrors.Unauthenticated:    codes.Unauthenticated,
+	}
+	for code, expected := range tests {
+		assert.Equal(t, expected, FromCode(code), code.String())
+		assert.Equal(t, code, ToCode(expected), code.String())
+	}
+}
+
+func TestFromError(t *testing.T) {
+	assert.Equal(t, codes.OK, FromError(nil))
+	assert.Equal(t, codes.Unknown, FromError(errors.New("external")))
+	assert.Equal(t, codes.NotFound, FromError(derrors.WithCode(errors.New("external"), derrors.NotFound)))
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// PanicError holds the value of a recovered panic and the stack of the panicking goroutine.
+// Both PanicError and closer.PanicError have the PanicValue method, use PanicValue to match either.
+type PanicError struct {
+	Value any
+	Stack Stack
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func (e *PanicError) StackTrace() Stack {
+	return e.Stack
+}
+
+// PanicValue returns the panic value.
+func (e *PanicError) PanicValue() any {
+	return e.Value
+}
+
+// LogValue returns the slog value of the error (see LogValue).
+func (e *PanicError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// Format formats the error with %+v as the error message followed by the stack of the panic.
+func (e *PanicError) Format(f fmt.State, verb rune) {
+	if verb == 'q' {
+		_, _ = fmt.Fprintf(f, "%q", e.Error())
+		return
+	}
+
+	_, _ = io.WriteString(f, e.Error())
+	if verb == 'v' && f.Flag('+') {
+		e.Stack.Format(f, verb)
+	}
+}
+
+type recoverOptions struct {
+	matches []func(v any) bool
+}
+
+// RecoverOption configures Recover.
+type RecoverOption func(recoverOptions) recoverOptions
+
+// Only makes Recover convert only the panics with values of type T (or implementing T if it is
+// an interface, e.g. runtime.Error). Other panics are rethrown. Several Only options are combined.
+func Only[T any]() RecoverOption {
+	return func(opts recoverOptions) recoverOptions {
+		opts.matches = append(opts.matches, func(v any) bool {
+			_, ok := v.(T)
+			return ok
+		})
+		return opts
+	}
+}
+
+// Recover converts a panic into PanicError and joins it into the error (see Join).
+// It must be deferred directly:
+//
+//	func Handle(ctx context.Context, req Request) (err error) {
+//		defer derrors.Recover(&err)
+//		...
+//	}
+//
+// If err is nil, the panic is not recovered. Panics which don't match Only options are rethrown
+// with the same value.
+func Recover(err *error, opts ...RecoverOption) { //nolint:gocritic // ptrToRefParam here is OK
+	if err == nil {
+		return
+	}
+
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	var o recoverOptions
+	for _, opt := range opts {
+		o = opt(o)
+	}
+	if !o.match(v) {
+		panic(v)
+	}
+
+	Join(err, &PanicError{
+		Value: v,
+		Stack: callers(),
+	})
+}
+
+// PanicValue returns the value of the first recovered panic in the error tree:
+// a PanicError or any other error with the PanicValue method, e.g. closer.PanicError.
+func PanicValue(err error) (v any, ok bool) {
+	var panicErr interface{ PanicValue() any }
+	if !errors.As(err, &panicErr) {
+		return nil, false
+	}
+	return panicErr.PanicValue(), true
+}
+
+func (o recoverOptions) match(v any) bool {
+	if len(o.matches) == 0 {
+		return true
+	}
+	for _, match := range o.matches {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
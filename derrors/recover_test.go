@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	. "github.com/nbgrp/pkg/derrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func panicking(v any) {
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Recover(&err)
+			panicking("boom")
+			return nil
+		}
+
+		err := fn()
+
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.EqualError(t, err, "panic: boom")
+		assert.Contains(t, fmt.Sprintf("%+v", err), "derrors_test.panicking")
+		assert.Same(t, err, WithStack(err))
+
+		v, ok := PanicValue(fmt.Errorf("handle: %w", err))
+		assert.True(t, ok)
+		assert.Equal(t, "boom", v)
+	})
+
+	t.Run("joined", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Recover(&err)
+			defer func() {
+				err = errInternal
+				panicking(errExternal)
+			}()
+			return nil
+		}
+
+		err := fn()
+
+		require.ErrorIs(t, err, errInternal)
+		require.ErrorIs(t, err, errExternal)
+		assert.EqualError(t, err, "internal\npanic: external")
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Recover(&err)
+			return errInternal
+		}
+
+		assert.Same(t, errInternal, fn())
+	})
+
+	t.Run("only", func(t *testing.T) {
+		fn := func(f func()) (err error) {
+			defer Recover(&err, Only[runtime.Error](), Only[string]())
+			f()
+			return nil
+		}
+
+		var runtimeErr runtime.Error
+		require.ErrorAs(t, fn(func() {
+			var m map[string]int
+			m["key"] = 1
+		}), &runtimeErr)
+		require.Error(t, fn(func() { panicking("boom") }))
+		assert.PanicsWithValue(t, 42, func() { _ = fn(func() { panicking(42) }) })
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "boom", func() {
+			defer Recover(nil)
+			panicking("boom")
+		})
+	})
+}
+
+// closePanicError mimics closer.PanicError.
+type closePanicError struct {
+	value any
+}
+
+func (e *closePanicError) Error() string {
+	return fmt.Sprintf("close panic: %v", e.value)
+}
+
+func (e *closePanicError) PanicValue() any {
+	return e.value
+}
+
+func TestPanicValue(t *testing.T) {
+	v, ok := PanicValue(errors.Join(errInternal, &closePanicError{value: 42}))
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+
+	_, ok = PanicValue(errInternal)
+	assert.False(t, ok)
+	_, ok = PanicValue(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strconv"
+)
+
+// maxStackDepth limits the number of recorded stack frames.
+const maxStackDepth = 32
+
+// Stack is a stack of program counters. It is symbolized only when formatted or
+// its frames are requested, so capturing it is cheap.
+type Stack []uintptr
+
+// Frames returns symbolized frames of the stack.
+func (s Stack) Frames() []runtime.Frame {
+	if len(s) == 0 {
+		return nil
+	}
+
+	frames := make([]runtime.Frame, 0, len(s))
+	it := runtime.CallersFrames(s)
+	for {
+		frame, more := it.Next()
+		frames = append(frames, frame)
+		if !more {
+			return frames
+		}
+	}
+}
+
+// Format formats the stack with %+v as function names followed by file:line on separate lines.
+func (s Stack) Format(f fmt.State, verb rune) {
+	if verb != 'v' || !f.Flag('+') {
+		return
+	}
+
+	for _, frame := range s.Frames() {
+		_, _ = io.WriteString(f, "\n"+frame.Function+"\n\t"+frame.File+":"+strconv.Itoa(frame.Line))
+	}
+}
+
+// StackTracer is implemented by errors which record the stack of their creation.
+type StackTracer interface {
+	StackTrace() Stack
+}
+
+type stackError struct {
+	err   error
+	stack Stack
+}
+
+// New returns an error with the message and the stack of the caller.
+func New(msg string) error {
+	return &stackError{
+		err:   errors.New(msg),
+		stack: callers(),
+	}
+}
+
+// WithStack records the stack of the caller into the error.
+// It returns nil for nil error and the error itself if it already has a stack.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var tracer StackTracer
+	if errors.As(err, &tracer) {
+		return err
+	}
+
+	return &stackError{
+		err:   err,
+		stack: callers(),
+	}
+}
+
+func (e *stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+func (e *stackError) StackTrace() Stack {
+	return e.stack
+}
+
+// LogValue returns the slog value of the error (see LogValue).
+func (e *stackError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// Format formats the error with %+v as the error message followed by its stack.
+func (e *stackError) Format(f fmt.State, verb rune) {
+	formatError(f, verb, e.err)
+	if verb == 'v' && f.Flag('+') {
+		e.stack.Format(f, verb)
+	}
+}
+
+// callers returns the stack of the caller of the function which calls callers.
+func callers() Stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	return append(Stack(nil), pcs[:n]...)
+}
+
+// formatError formats the error keeping the %+v verb for the nested errors.
+func formatError(f fmt.State, verb rune, err error) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		_, _ = fmt.Fprintf(f, "%+v", err)
+	case verb == 'q':
+		_, _ = fmt.Fprintf(f, "%q", err.Error())
+	default:
+		_, _ = io.WriteString(f, err.Error())
+	}
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/nbgrp/pkg/derrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newError() error {
+	return New("new error")
+}
+
+func withStack() error {
+	return WithStack(errExternal)
+}
+
+func TestStack(t *testing.T) {
+	t.Run("new", func(t *testing.T) {
+		err := newError()
+
+		var tracer StackTracer
+		require.ErrorAs(t, err, &tracer)
+		frames := tracer.StackTrace().Frames()
+		require.NotEmpty(t, frames)
+		assert.Equal(t, "github.com/nbgrp/pkg/derrors_test.newError", frames[0].Function)
+
+		assert.Equal(t, "new error", fmt.Sprintf("%v", err))
+		assert.Equal(t, `"new error"`, fmt.Sprintf("%q", err))
+		assert.Regexp(t, "^new error\n"+
+			"github.com/nbgrp/pkg/derrors_test.newError\n"+
+			"\t.+/derrors/stack_test.go:16\n"+
+			"github.com/nbgrp/pkg/derrors_test.TestStack.func1\n", fmt.Sprintf("%+v", err))
+	})
+
+	t.Run("with stack", func(t *testing.T) {
+		err := withStack()
+
+		require.ErrorIs(t, err, errExternal)
+		assert.Equal(t, "external", err.Error())
+		assert.Contains(t, fmt.Sprintf("%+v", err), "derrors_test.withStack\n")
+	})
+
+	t.Run("with stack keeps existing stack", func(t *testing.T) {
+		err := newError()
+		require.Same(t, err, WithStack(err))
+
+		wrapped := fmt.Errorf("wrapped: %w", err)
+		require.Same(t, wrapped, WithStack(wrapped))
+	})
+
+	t.Run("with stack nil", func(t *testing.T) {
+		require.NoError(t, WithStack(nil))
+	})
+
+	t.Run("join keeps stacks", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Join(&err, withStack())
+			return newError()
+		}
+
+		err := fn()
+
+		require.ErrorIs(t, err, errExternal)
+		assert.Equal(t, "new error\nexternal", err.Error())
+		assert.Equal(t, "new error\nexternal", fmt.Sprintf("%v", err))
+
+		out := fmt.Sprintf("%+v", err)
+		assert.Contains(t, out, "new error\ngithub.com/nbgrp/pkg/derrors_test.newError\n")
+		assert.Contains(t, out, "\nexternal\ngithub.com/nbgrp/pkg/derrors_test.withStack\n")
+
+		var joined interface{ Unwrap() []error }
+		require.True(t, errors.As(err, &joined))
+		assert.Len(t, joined.Unwrap(), 2)
+	})
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors
+
+// Walk calls fn for the error and every error of its tree, i.e. the errors it wraps
+// (Unwrap() error) or joins (Unwrap() []error), in depth-first pre-order.
+// The walk stops when fn returns false. Walk reports whether the walk was completed.
+func Walk(err error, fn func(error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if !fn(err) {
+		return false
+	}
+
+	switch e := err.(type) { //nolint:errorlint // the tree is traversed explicitly
+	case interface{ Unwrap() error }:
+		return Walk(e.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if !Walk(err, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Flatten returns the errors joined in the error tree. Nested joined errors (which unwrap to
+// multiple errors, like the results of Join and errors.Join) are flattened, other errors
+// are returned as is, i.e. a wrapped joined error is not flattened. It returns nil for nil error.
+func Flatten(err error) []error {
+	var errs []error
+	flatten(err, &errs)
+	return errs
+}
+
+func flatten(err error, errs *[]error) {
+	if err == nil {
+		return
+	}
+
+	multi, ok := err.(interface{ Unwrap() []error }) //nolint:errorlint // only the error itself is checked
+	if !ok {
+		*errs = append(*errs, err)
+		return
+	}
+	for _, err := range multi.Unwrap() {
+		flatten(err, errs)
+	}
+}
+
+// Filter returns the joined flattened errors (see Flatten) for which pred returns true.
+// It returns the single error as is and nil if there are no such errors, e.g.
+//
+//	err = derrors.Filter(err, func(err error) bool { return !errors.Is(err, context.Canceled) })
+func Filter(err error, pred func(error) bool) error {
+	var kept []error
+	for _, err := range Flatten(err) {
+		if pred(err) {
+			kept = append(kept, err)
+		}
+	}
+
+	if len(kept) == 1 {
+		return kept[0]
+	}
+	return join(kept...)
+}
+
+// Count returns the number of the flattened errors (see Flatten).
+func Count(err error) int {
+	return len(Flatten(err))
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/nbgrp/pkg/derrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	wrapped := fmt.Errorf("wrapped: %w", errInternal)
+	joined := errors.Join(wrapped, errExternal)
+	err := errors.Join(joined, context.Canceled)
+
+	t.Run("all", func(t *testing.T) {
+		var visited []error
+		completed := Walk(err, func(err error) bool {
+			visited = append(visited, err)
+			return true
+		})
+
+		assert.True(t, completed)
+		assert.Equal(t, []error{err, joined, wrapped, errInternal, errExternal, context.Canceled}, visited)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var visited []error
+		completed := Walk(err, func(err error) bool {
+			visited = append(visited, err)
+			return err != errInternal //nolint:errorlint // identity is checked
+		})
+
+		assert.False(t, completed)
+		assert.Equal(t, []error{err, joined, wrapped, errInternal}, visited)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		assert.True(t, Walk(nil, func(error) bool {
+			t.Fatal("unexpected call")
+			return true
+		}))
+	})
+}
+
+func TestFlatten(t *testing.T) {
+	wrapped := fmt.Errorf("wrapped: %w", errors.Join(errInternal, errExternal))
+
+	var err error = errors.Join(errInternal, errors.Join(errExternal, context.Canceled))
+	Join(&err, wrapped, nil)
+
+	assert.Equal(t, []error{errInternal, errExternal, context.Canceled, wrapped}, Flatten(err))
+	assert.Equal(t, []error{errExternal}, Flatten(errExternal))
+	assert.Nil(t, Flatten(nil))
+}
+
+func TestFilter(t *testing.T) {
+	notCanceled := func(err error) bool {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	t.Run("multiple", func(t *testing.T) {
+		err := Filter(errors.Join(errInternal, context.Canceled, errExternal), notCanceled)
+
+		assert.Equal(t, []error{errInternal, errExternal}, Flatten(err))
+		assert.EqualError(t, err, "internal\nexternal")
+	})
+
+	t.Run("single", func(t *testing.T) {
+		err := Filter(errors.Join(errInternal, fmt.Errorf("op: %w", context.Canceled)), notCanceled)
+
+		assert.Same(t, errInternal, err)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		require.NoError(t, Filter(errors.Join(context.Canceled, context.Canceled), notCanceled))
+		require.NoError(t, Filter(nil, notCanceled))
+	})
+}
+
+func TestCount(t *testing.T) {
+	assert.Equal(t, 3, Count(errors.Join(errInternal, errors.Join(errExternal, context.Canceled))))
+	assert.Equal(t, 1, Count(errExternal))
+	assert.Equal(t, 0, Count(nil))
+}
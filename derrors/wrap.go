@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// Wrap prefixes non-nil error with the formatted message. It is intended to be deferred:
+//
+//	func Open(name string) (err error) {
+//		defer derrors.Wrap(&err, "open %s", name)
+//		...
+//	}
+//
+// The original error is wrapped, so errors.Is and errors.As keep working, and the attributes
+// (see With) and %+v formatting of the original error are kept.
+func Wrap(err *error, format string, args ...any) { //nolint:gocritic // ptrToRefParam here is OK
+	if err == nil || *err == nil {
+		return
+	}
+	*err = &wrapError{
+		msg: fmt.Sprintf(format, args...),
+		err: *err,
+	}
+}
+
+// WrapCaller is like Wrap but prefixes non-nil error with the name of the function which defers it.
+func WrapCaller(err *error) { //nolint:gocritic // ptrToRefParam here is OK
+	if err == nil || *err == nil {
+		return
+	}
+	*err = &wrapError{
+		msg: callerName(2),
+		err: *err,
+	}
+}
+
+// wrapError is like the fmt.Errorf("%s: %w") error, but it keeps the attributes
+// and %+v formatting of the wrapped error.
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *wrapError) Unwrap() error {
+	return e.err
+}
+
+// LogValue returns the slog value of the error (see LogValue).
+func (e *wrapError) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// Format formats the error with %+v as the message followed by the wrapped error formatted with %+v.
+func (e *wrapError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		_, _ = fmt.Fprintf(f, "%s: %+v", e.msg, e.err)
+		return
+	}
+	formatError(f, verb, e)
+}
+
+// callerName returns the function name without the package path, e.g. "derrors.Wrap".
+func callerName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+package derrors_test
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"testing"
+
+	. "github.com/nbgrp/pkg/derrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reader struct{}
+
+func (reader) read() (err error) {
+	defer WrapCaller(&err)
+	return errInternal
+}
+
+func loadConfig() (err error) {
+	defer WrapCaller(&err)
+	return New("boom")
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		fn := func(name string) (err error) {
+			defer Wrap(&err, "open %s", name)
+			_, err = os.Open(name)
+			return err
+		}
+
+		err := fn("/nonexistent")
+
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		var pathErr *fs.PathError
+		require.ErrorAs(t, err, &pathErr)
+		assert.EqualError(t, err, "open /nonexistent: open /nonexistent: no such file or directory")
+	})
+
+	t.Run("no error", func(t *testing.T) {
+		fn := func() (err error) {
+			defer Wrap(&err, "noop")
+			return nil
+		}
+
+		require.NoError(t, fn())
+	})
+
+	t.Run("caller", func(t *testing.T) {
+		err := reader{}.read()
+
+		require.ErrorIs(t, err, errInternal)
+		assert.EqualError(t, err, "derrors_test.reader.read: internal")
+	})
+
+	t.Run("caller without error", func(t *testing.T) {
+		fn := func() (err error) {
+			defer WrapCaller(&err)
+			return nil
+		}
+
+		require.NoError(t, fn())
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		fn := func(id int) (err error) {
+			defer Wrap(&err, "load user %d", id)
+			return With(errInternal, "user_id", id)
+		}
+
+		err := fn(7)
+
+		assert.EqualError(t, err, "load user 7: internal")
+		assert.Equal(t, []slog.Attr{slog.Int("user_id", 7)}, Attrs(err))
+		assert.Equal(t, slog.KindGroup, err.(slog.LogValuer).LogValue().Kind()) //nolint:errorlint // the wrapper itself is checked
+	})
+
+	t.Run("stack", func(t *testing.T) {
+		err := loadConfig()
+
+		assert.Equal(t, "derrors_test.loadConfig: boom", fmt.Sprintf("%v", err))
+		assert.Regexp(t, `^derrors_test\.loadConfig: boom\n.+derrors_test\.loadConfig\n`, fmt.Sprintf("%+v", err))
+		assert.Equal(t, `"derrors_test.loadConfig: boom"`, fmt.Sprintf("%q", err))
+	})
+
+	t.Run("no panic with nil error reference", func(t *testing.T) {
+		Wrap(nil, "noop")
+		WrapCaller(nil)
+	})
+}